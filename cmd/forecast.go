@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/previousnext/k8s-aws-autoscaler/internal/scaler"
+)
+
+type cmdForecast struct {
+	params scaler.ForecastParams
+}
+
+func (cmd *cmdForecast) run(c *kingpin.ParseContext) error {
+	return scaler.Forecast(os.Stdout, cmd.params)
+}
+
+// Forecast declares the "forecast" sub command.
+func Forecast(app *kingpin.Application) {
+	c := new(cmdForecast)
+
+	cmd := app.Command("forecast", "Print the forecast demand next to the recorded history").Action(c.run)
+	cmd.Flag("history-path", "The file which demand is recorded to").Default(scaler.DefaultHistoryPath).Envar("HISTORY_PATH").StringVar(&c.params.Predictive.HistoryPath)
+	cmd.Flag("group", "Only print the forecast for this Autoscaling group").Envar("GROUP").StringVar(&c.params.Group)
+	cmd.Flag("lookback", "How much of the recorded history to print before the forecast").Default("6h").Envar("LOOKBACK").DurationVar(&c.params.Lookback)
+	cmd.Flag("forecast-horizon", "How far ahead to forecast demand").Default("1h").Envar("FORECAST_HORIZON").DurationVar(&c.params.Predictive.Horizon)
+	cmd.Flag("forecast-step", "The interval between forecast points").Default("5m").Envar("FORECAST_STEP").DurationVar(&c.params.Predictive.Step)
+	cmd.Flag("forecast-margin", "Percentage added to the forecast demand").Default("10").Envar("FORECAST_MARGIN").Float64Var(&c.params.Predictive.Margin)
+}
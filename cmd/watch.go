@@ -1,18 +1,83 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/alecthomas/kingpin"
+	"github.com/pkg/errors"
 	"github.com/previousnext/k8s-aws-autoscaler/internal/scaler"
 )
 
 type cmdWatch struct {
-	params scaler.WatchParams
+	params    scaler.WatchParams
+	groups    []string
+	schedules []string
+	// Deprecated scale down timeout in minutes, empty when not declared.
+	scaleDownTimeout string
+	cronJobTimezone  string
 }
 
 func (cmd *cmdWatch) run(c *kingpin.ParseContext) error {
-	return scaler.Watch(os.Stdout, cmd.params)
+	// The scale down timeout was replaced by the scale down cooldown and delay after scaling up, which it both applied to.
+	if cmd.scaleDownTimeout != "" {
+		minutes, err := strconv.ParseFloat(cmd.scaleDownTimeout, 64)
+		if err != nil || minutes < 0 {
+			return errors.Errorf("invalid scale down timeout: %s", cmd.scaleDownTimeout)
+		}
+
+		timeout := time.Duration(minutes * float64(time.Minute))
+
+		fmt.Fprintf(os.Stdout, "WARNING: --scale-down-timeout / SCALE_DOWN_TIMEOUT is deprecated, use --scale-down-cooldown and --scale-down-delay-after-up instead (setting both to %s)\n", timeout)
+
+		cmd.params.Cooldown.Down = timeout
+		cmd.params.Cooldown.DownAfterUp = timeout
+	}
+
+	for _, spec := range cmd.groups {
+		group, err := scaler.ParseGroup(spec)
+		if err != nil {
+			return err
+		}
+
+		cmd.params.Groups = append(cmd.params.Groups, group)
+	}
+
+	for _, spec := range cmd.schedules {
+		schedule, err := scaler.ParseSchedule(spec)
+		if err != nil {
+			return err
+		}
+
+		cmd.params.Schedules = append(cmd.params.Schedules, schedule)
+	}
+
+	loc, err := time.LoadLocation(cmd.cronJobTimezone)
+	if err != nil {
+		return errors.Wrap(err, "invalid CronJob time zone")
+	}
+
+	cmd.params.Sources.CronJobLocation = loc
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Shutdown gracefully when the pod is deleted, so drains which are in flight aren't left half done.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		fmt.Fprintf(os.Stdout, "Shutting down because: received %s\n", sig)
+		cancel()
+	}()
+
+	return scaler.Watch(ctx, os.Stdout, cmd.params)
 }
 
 // Watch declares the "watch" sub command.
@@ -20,10 +85,69 @@ func Watch(app *kingpin.Application) {
 	c := new(cmdWatch)
 
 	cmd := app.Command("watch", "Watch to capacity changes").Action(c.run)
-	cmd.Flag("group", "The Autoscaling group to update periodically").Required().Envar("GROUP").StringVar(&c.params.Group)
+	cmd.Flag("group", "The Autoscaling group to update periodically, with optional options eg. name;node-cpu=3800;min=1;max=10;namespaces=a,b;selector=tier=web (repeatable)").Envar("GROUP").StringsVar(&c.groups)
+	cmd.Flag("discover-tag", "Also manage Autoscaling groups which have this tag, declared as key or key=value (repeatable)").Envar("DISCOVER_TAGS").StringsVar(&c.params.DiscoveryTags)
+	cmd.Flag("cluster-name", "Also manage Autoscaling groups tagged with kubernetes.io/cluster/<name>").Envar("CLUSTER_NAME").StringVar(&c.params.ClusterName)
 	cmd.Flag("frequency", "How often to run the check").Default("120s").Envar("FREQUENCY").DurationVar(&c.params.Frequency)
-	cmd.Flag("scale-down-timeout", "How long to wait before scaling down (in minutes)").Default("60").Envar("SCALE_DOWN_TIMEOUT").Float64Var(&c.params.DownTimeout)
+	cmd.Flag("scale-up-cooldown", "How long to wait after scaling up before scaling up again").Default("0s").Envar("SCALE_UP_COOLDOWN").DurationVar(&c.params.Cooldown.Up)
+	cmd.Flag("scale-down-cooldown", "How long to wait after scaling down before scaling down again").Default("60m").Envar("SCALE_DOWN_COOLDOWN").DurationVar(&c.params.Cooldown.Down)
+	cmd.Flag("scale-down-delay-after-up", "How long to wait after scaling up before scaling down").Default("60m").Envar("SCALE_DOWN_DELAY_AFTER_UP").DurationVar(&c.params.Cooldown.DownAfterUp)
+	cmd.Flag("scale-down-timeout", "Deprecated: use --scale-down-cooldown and --scale-down-delay-after-up. How long to wait before scaling down (in minutes)").Envar("SCALE_DOWN_TIMEOUT").StringVar(&c.scaleDownTimeout)
+	cmd.Flag("scale-up-stabilization", "Only scale up to the lowest capacity desired within this window").Default("0s").Envar("SCALE_UP_STABILIZATION").DurationVar(&c.params.Stabilization.UpWindow)
+	cmd.Flag("scale-down-stabilization", "Only scale down to the highest capacity desired within this window").Default("0s").Envar("SCALE_DOWN_STABILIZATION").DurationVar(&c.params.Stabilization.DownWindow)
+	cmd.Flag("scale-up-max-nodes", "How many nodes can be added within each scale up period (0 for unlimited)").Default("0").Envar("SCALE_UP_MAX_NODES").IntVar(&c.params.Stabilization.UpMaxNodes)
+	cmd.Flag("scale-up-max-percent", "Percentage of the group which can be added within each scale up period (0 for unlimited)").Default("0").Envar("SCALE_UP_MAX_PERCENT").Float64Var(&c.params.Stabilization.UpMaxPercent)
+	cmd.Flag("scale-up-period", "The period which --scale-up-max-nodes and --scale-up-max-percent apply to").Default("10m").Envar("SCALE_UP_PERIOD").DurationVar(&c.params.Stabilization.UpPeriod)
+	cmd.Flag("schedule", "Raise the minimum or cap the maximum size of groups on a schedule eg. business-hours;cron=30 7 * * Mon-Fri;duration=10h30m;timezone=Australia/Sydney;min=8 (repeatable)").Envar("SCHEDULES").StringsVar(&c.schedules)
+	cmd.Flag("predictive", "Record demand and scale ahead of the demand forecast from the same time on the previous day and week").Envar("PREDICTIVE").BoolVar(&c.params.Predictive.Enabled)
+	cmd.Flag("history-path", "The file which demand is recorded to for predictive scaling").Default(scaler.DefaultHistoryPath).Envar("HISTORY_PATH").StringVar(&c.params.Predictive.HistoryPath)
+	cmd.Flag("forecast-horizon", "How far ahead to forecast demand for predictive scaling").Default("1h").Envar("FORECAST_HORIZON").DurationVar(&c.params.Predictive.Horizon)
+	cmd.Flag("forecast-step", "The interval between forecast points for predictive scaling").Default("5m").Envar("FORECAST_STEP").DurationVar(&c.params.Predictive.Step)
+	cmd.Flag("forecast-margin", "Percentage added to the forecast demand for predictive scaling").Default("10").Envar("FORECAST_MARGIN").Float64Var(&c.params.Predictive.Margin)
+	cmd.Flag("leader-elect", "Elect a leader between replicas, so only the leader makes changes").Envar("LEADER_ELECT").BoolVar(&c.params.LeaderElection.Enabled)
+	cmd.Flag("leader-elect-namespace", "Namespace of the ConfigMap which is used as the leader election lock").Default("kube-system").Envar("LEADER_ELECT_NAMESPACE").StringVar(&c.params.LeaderElection.Namespace)
+	cmd.Flag("leader-elect-name", "Name of the ConfigMap which is used as the leader election lock").Default("k8s-aws-autoscaler-leader").Envar("LEADER_ELECT_NAME").StringVar(&c.params.LeaderElection.Name)
+	cmd.Flag("leader-elect-identity", "Identity of this replica in the leader election (defaults to the hostname)").Envar("LEADER_ELECT_IDENTITY").StringVar(&c.params.LeaderElection.Identity)
+	cmd.Flag("leader-elect-lease-duration", "How long standby replicas wait before taking over from a leader which stopped renewing").Default("15s").Envar("LEADER_ELECT_LEASE_DURATION").DurationVar(&c.params.LeaderElection.LeaseDuration)
+	cmd.Flag("leader-elect-renew-deadline", "How long the leader retries renewing before it stops acting as the leader").Default("10s").Envar("LEADER_ELECT_RENEW_DEADLINE").DurationVar(&c.params.LeaderElection.RenewDeadline)
+	cmd.Flag("leader-elect-retry-period", "How long to wait between attempts to acquire or renew the leadership").Default("2s").Envar("LEADER_ELECT_RETRY_PERIOD").DurationVar(&c.params.LeaderElection.RetryPeriod)
+	cmd.Flag("listen", "Address to serve the /healthz and /metrics endpoints on (disabled when empty)").Default(":8080").Envar("LISTEN").StringVar(&c.params.ListenAddress)
+	cmd.Flag("state-namespace", "Namespace of the ConfigMap which the scaler's state is persisted in").Default("kube-system").Envar("STATE_NAMESPACE").StringVar(&c.params.State.Namespace)
+	cmd.Flag("state-configmap", "Name of the ConfigMap which the scaler's state is persisted in").Default("k8s-aws-autoscaler").Envar("STATE_CONFIGMAP").StringVar(&c.params.State.Name)
+	cmd.Flag("retry-attempts", "How many attempts are made for each API call which fails with a transient error").Default("4").Envar("RETRY_ATTEMPTS").IntVar(&c.params.Retry.Attempts)
+	cmd.Flag("retry-backoff", "How long to wait before the first retry, doubling on each retry").Default("1s").Envar("RETRY_BACKOFF").DurationVar(&c.params.Retry.Backoff)
+	cmd.Flag("retry-max-backoff", "The longest to wait between retries").Default("30s").Envar("RETRY_MAX_BACKOFF").DurationVar(&c.params.Retry.MaxBackoff)
+	cmd.Flag("hold-after", "Stop making changes after this many consecutive failed cycles, until a cycle which makes changes succeeds (disabled when 0)").Default("5").Envar("HOLD_AFTER").IntVar(&c.params.Retry.HoldAfter)
+	cmd.Flag("shutdown-timeout", "How long drains which are in flight are given to finish when shutting down, before they are rolled back").Default("20s").Envar("SHUTDOWN_TIMEOUT").DurationVar(&c.params.ShutdownTimeout)
+	cmd.Flag("drain-timeout", "How long to wait for pods to be evicted from a node before it is removed").Default("10m").Envar("DRAIN_TIMEOUT").DurationVar(&c.params.DrainTimeout)
+	cmd.Flag("protect-instances", "Protect the instances of nodes which can't be removed from scale in, so they can only be removed by draining them").Envar("PROTECT_INSTANCES").BoolVar(&c.params.ProtectInstances)
+	cmd.Flag("lifecycle-hooks", "Drain the nodes of instances which are waiting on an EC2_INSTANCE_TERMINATING lifecycle hook").Envar("LIFECYCLE_HOOKS").BoolVar(&c.params.LifecycleHooks)
+	cmd.Flag("lifecycle-heartbeat", "How often to extend a lifecycle hook while its node is being drained").Default("60s").Envar("LIFECYCLE_HEARTBEAT").DurationVar(&c.params.LifecycleHeartbeat)
 	cmd.Flag("dry", "Don't make any changes!").BoolVar(&c.params.DryRun)
 	cmd.Flag("node-cpu", "Declare how much cpu the node has in the scaling group").Default("200").Envar("NODE_CPU").IntVar(&c.params.NodeCPU)
 	cmd.Flag("node-mem", "Declare how much memory the node has in the scaling group").Default("7000").Envar("NODE_MEM").IntVar(&c.params.NodeMemory)
+	cmd.Flag("node-pods", "Declare how many pods the node can run in the scaling group").Default("110").Envar("NODE_PODS").IntVar(&c.params.NodePods)
+	cmd.Flag("kube-reserved-cpu", "Declare how much cpu is reserved on each node for Kubernetes system daemons").Default("0").Envar("KUBE_RESERVED_CPU").IntVar(&c.params.KubeReservedCPU)
+	cmd.Flag("kube-reserved-mem", "Declare how much memory is reserved on each node for Kubernetes system daemons").Default("0").Envar("KUBE_RESERVED_MEM").IntVar(&c.params.KubeReservedMemory)
+	cmd.Flag("system-reserved-cpu", "Declare how much cpu is reserved on each node for OS system daemons").Default("0").Envar("SYSTEM_RESERVED_CPU").IntVar(&c.params.SystemReservedCPU)
+	cmd.Flag("system-reserved-mem", "Declare how much memory is reserved on each node for OS system daemons").Default("0").Envar("SYSTEM_RESERVED_MEM").IntVar(&c.params.SystemReservedMemory)
+	cmd.Flag("headroom-nodes", "Spare nodes which are added on top of the demand").Default("0").Envar("HEADROOM_NODES").IntVar(&c.params.Headroom.Nodes)
+	cmd.Flag("headroom-percent", "Percentage of the demand which is added as spare nodes").Default("0").Envar("HEADROOM_PERCENT").Float64Var(&c.params.Headroom.Percent)
+	cmd.Flag("headroom-cpu", "Spare cpu which is added on top of the demand, rounded up to whole nodes").Default("0").Envar("HEADROOM_CPU").IntVar(&c.params.Headroom.CPU)
+	cmd.Flag("headroom-mem", "Spare memory which is added on top of the demand, rounded up to whole nodes").Default("0").Envar("HEADROOM_MEM").IntVar(&c.params.Headroom.Memory)
+	cmd.Flag("strategy", "How to calculate the desired amount of nodes (binpack or aggregate)").Default(scaler.StrategyBinPack).Envar("STRATEGY").EnumVar(&c.params.Strategy, scaler.StrategyBinPack, scaler.StrategyAggregate)
+	cmd.Flag("source-deployments", "Count Deployments towards capacity demand").Default("true").Envar("SOURCE_DEPLOYMENTS").BoolVar(&c.params.Sources.Deployments)
+	cmd.Flag("source-statefulsets", "Count StatefulSets towards capacity demand").Default("true").Envar("SOURCE_STATEFULSETS").BoolVar(&c.params.Sources.StatefulSets)
+	cmd.Flag("source-replicasets", "Count ReplicaSets which are not owned by a Deployment towards capacity demand").Default("true").Envar("SOURCE_REPLICASETS").BoolVar(&c.params.Sources.ReplicaSets)
+	cmd.Flag("source-daemonsets", "Deduct the DaemonSets which run on every node from the node capacity").Default("true").Envar("SOURCE_DAEMONSETS").BoolVar(&c.params.Sources.DaemonSets)
+	cmd.Flag("source-jobs", "Count Jobs which have not finished towards capacity demand").Default("true").Envar("SOURCE_JOBS").BoolVar(&c.params.Sources.Jobs)
+	cmd.Flag("source-cronjobs", "Count CronJobs which are scheduled to run within the lead time towards capacity demand").Default("true").Envar("SOURCE_CRONJOBS").BoolVar(&c.params.Sources.CronJobs)
+	cmd.Flag("cronjob-lead-time", "How long before a CronJob is scheduled to run that its Jobs are counted towards capacity demand").Default("10m").Envar("CRONJOB_LEAD_TIME").DurationVar(&c.params.Sources.CronJobLeadTime)
+	cmd.Flag("cronjob-timezone", "The time zone CronJob schedules are evaluated in, which is the local time zone of kube-controller-manager").Default("UTC").Envar("CRONJOB_TIMEZONE").StringVar(&c.cronJobTimezone)
+	cmd.Flag("namespace", "Only count workloads in these namespaces (all namespaces when not set)").Envar("NAMESPACES").StringsVar(&c.params.Filter.Namespaces)
+	cmd.Flag("exclude-namespace", "Don't count workloads in these namespaces").Envar("EXCLUDE_NAMESPACES").StringsVar(&c.params.Filter.ExcludeNamespaces)
+	cmd.Flag("selector", "Only count workloads with labels matching this selector").Envar("SELECTOR").StringVar(&c.params.Filter.Selector)
+	cmd.Flag("pending-pods", "Scale up for pods which are unschedulable because of insufficient resources").Default("true").Envar("PENDING_PODS").BoolVar(&c.params.PendingPods)
+	cmd.Flag("hpa-mode", "How to count replicas of workloads managed by a HorizontalPodAutoscaler (current, min, max or percentile)").Default(scaler.HPAModeCurrent).Envar("HPA_MODE").EnumVar(&c.params.HPAMode, scaler.HPAModes...)
+	cmd.Flag("hpa-percentile", "Percentile between the minimum and maximum replicas counted by the percentile HPA mode").Default("50").Envar("HPA_PERCENTILE").IntVar(&c.params.HPAPercentile)
 }
@@ -0,0 +1,15 @@
+package scaler
+
+const (
+	// AnnotationIgnore opts a workload out of being counted towards capacity demand when set to "true".
+	AnnotationIgnore = "autoscaler.previousnext/ignore"
+	// AnnotationHPAMode overrides how replicas are counted for a workload managed by a HorizontalPodAutoscaler.
+	AnnotationHPAMode = "autoscaler.previousnext/hpa-mode"
+	// AnnotationHPAPercentile overrides the percentile used by the "percentile" HPA mode for a workload.
+	AnnotationHPAPercentile = "autoscaler.previousnext/hpa-percentile"
+	// AnnotationSafeToEvict declares whether a pod can be evicted so its node can be removed.
+	// "false" stops the node being removed, while "true" allows pods which would otherwise be skipped eg. with local storage.
+	AnnotationSafeToEvict = "autoscaler.previousnext/safe-to-evict"
+	// AnnotationScaleDownDisabled stops a node from being removed when set to "true".
+	AnnotationScaleDownDisabled = "autoscaler.previousnext/scale-down-disabled"
+)
@@ -0,0 +1,98 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Helper function to filter the Nodes which belong to the autoscaling group.
+// Nodes are matched to the group's instances using the instance ID in their providerID.
+// Only instances which are in service are matched, instances which are launching or terminating are left out.
+func groupNodes(nodes []corev1.Node, asg *autoscaling.Group) []corev1.Node {
+	instances := make(map[string]bool)
+
+	for _, instance := range asg.Instances {
+		if instance.InstanceId != nil && aws.StringValue(instance.LifecycleState) == autoscaling.LifecycleStateInService {
+			instances[*instance.InstanceId] = true
+		}
+	}
+
+	var group []corev1.Node
+
+	for _, node := range nodes {
+		if instances[instanceID(node)] {
+			group = append(group, node)
+		}
+	}
+
+	return group
+}
+
+// Helper function to extract the EC2 instance ID from a Node's providerID.
+// eg. aws:///ap-southeast-2a/i-0123456789abcdef0 = i-0123456789abcdef0
+func instanceID(node corev1.Node) string {
+	id := node.Spec.ProviderID
+
+	if i := strings.LastIndex(id, "/"); i >= 0 {
+		id = id[i+1:]
+	}
+
+	return id
+}
+
+// Helper function to determine how much cpu, memory and pods a node in the group offers.
+// This is the smallest allocatable amount observed on the group's Nodes, falling back to
+// the declared amount when no Nodes have been registered.
+func getNodeCapacity(w io.Writer, nodes []corev1.Node, declared resources) resources {
+	if len(nodes) == 0 {
+		fmt.Fprintf(w, "No nodes are registered for the group, using declared node capacity CPU %d / Memory %d / Pods %d\n", declared.CPU, declared.Memory, declared.Pods)
+		return declared
+	}
+
+	var observed resources
+
+	for i, node := range nodes {
+		allocatable := nodeAllocatable(node)
+
+		if i == 0 || allocatable.CPU < observed.CPU {
+			observed.CPU = allocatable.CPU
+		}
+
+		if i == 0 || allocatable.Memory < observed.Memory {
+			observed.Memory = allocatable.Memory
+		}
+
+		if i == 0 || allocatable.Pods < observed.Pods {
+			observed.Pods = allocatable.Pods
+		}
+	}
+
+	if observed != declared {
+		fmt.Fprintf(w, "WARNING: Declared node capacity CPU %d / Memory %d / Pods %d does not match observed capacity CPU %d / Memory %d / Pods %d\n",
+			declared.CPU, declared.Memory, declared.Pods, observed.CPU, observed.Memory, observed.Pods)
+	}
+
+	fmt.Fprintf(w, "Using observed node capacity CPU %d / Memory %d / Pods %d\n", observed.CPU, observed.Memory, observed.Pods)
+
+	return observed
+}
+
+// Helper function to convert the allocatable status of a Node.
+func nodeAllocatable(node corev1.Node) resources {
+	var (
+		cpu  = node.Status.Allocatable[corev1.ResourceCPU]
+		mem  = node.Status.Allocatable[corev1.ResourceMemory]
+		pods = node.Status.Allocatable[corev1.ResourcePods]
+	)
+
+	return resources{
+		CPU:    int(cpu.MilliValue()),
+		Memory: int(mem.Value() / 1024.0 / 1024.0),
+		Pods:   int(pods.Value()),
+	}
+}
@@ -0,0 +1,42 @@
+package scaler
+
+import (
+	"time"
+)
+
+// CooldownParams declares how long to wait between scaling events.
+type CooldownParams struct {
+	// Up is how long to wait after scaling up before scaling up again.
+	Up time.Duration
+	// Down is how long to wait after scaling down before scaling down again.
+	Down time.Duration
+	// DownAfterUp is how long to wait after scaling up before scaling down.
+	DownAfterUp time.Duration
+}
+
+// cooldown records when a group was last scaled.
+type cooldown struct {
+	LastScaleUp   time.Time `json:"lastScaleUp"`
+	LastScaleDown time.Time `json:"lastScaleDown"`
+}
+
+// Helper function to determine how long a scaling event has to wait before it can be applied.
+func (c cooldown) remaining(params CooldownParams, up bool, now time.Time) time.Duration {
+	var until time.Time
+
+	if up {
+		until = c.LastScaleUp.Add(params.Up)
+	} else {
+		until = c.LastScaleDown.Add(params.Down)
+
+		if after := c.LastScaleUp.Add(params.DownAfterUp); after.After(until) {
+			until = after
+		}
+	}
+
+	if until.After(now) {
+		return until.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,76 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldownRemaining(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		params = CooldownParams{
+			Up:          5 * time.Minute,
+			Down:        30 * time.Minute,
+			DownAfterUp: 60 * time.Minute,
+		}
+	)
+
+	for _, tc := range []struct {
+		name      string
+		cooldown  cooldown
+		up        bool
+		remaining time.Duration
+	}{
+		{
+			name: "never scaled up",
+			up:   true,
+		},
+		{
+			name:      "scaling up after scaling up",
+			cooldown:  cooldown{LastScaleUp: now.Add(-2 * time.Minute)},
+			up:        true,
+			remaining: 3 * time.Minute,
+		},
+		{
+			name:     "scaling up once cooled down",
+			cooldown: cooldown{LastScaleUp: now.Add(-5 * time.Minute)},
+			up:       true,
+		},
+		{
+			name:     "scaling up isn't delayed by scaling down",
+			cooldown: cooldown{LastScaleDown: now},
+			up:       true,
+		},
+		{
+			name:      "scaling down after scaling down",
+			cooldown:  cooldown{LastScaleDown: now.Add(-10 * time.Minute)},
+			remaining: 20 * time.Minute,
+		},
+		{
+			name:      "scaling down after scaling up",
+			cooldown:  cooldown{LastScaleUp: now.Add(-10 * time.Minute)},
+			remaining: 50 * time.Minute,
+		},
+		{
+			name: "scaling down waits for the longest cooldown",
+			cooldown: cooldown{
+				LastScaleUp:   now.Add(-50 * time.Minute),
+				LastScaleDown: now.Add(-5 * time.Minute),
+			},
+			remaining: 25 * time.Minute,
+		},
+		{
+			name: "scaling down once cooled down",
+			cooldown: cooldown{
+				LastScaleUp:   now.Add(-2 * time.Hour),
+				LastScaleDown: now.Add(-time.Hour),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if remaining := tc.cooldown.remaining(params, tc.up, now); remaining != tc.remaining {
+				t.Errorf("expected %s, got %s", tc.remaining, remaining)
+			}
+		})
+	}
+}
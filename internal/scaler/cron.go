@@ -0,0 +1,218 @@
+package scaler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// How many steps to search for the next time a cron expression matches, before giving up on expressions which
+// never match eg. the 30th of February.
+const cronMaxSteps = 100000
+
+// Names which can be used instead of numbers in the month field.
+var cronMonths = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// Names which can be used instead of numbers in the day of week field.
+var cronWeekdays = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Descriptors which can be used instead of the five fields.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronExpression is a parsed cron expression with five fields: minute, hour, day of month, month and day of week.
+type cronExpression struct {
+	minutes  map[int]bool
+	hours    map[int]bool
+	days     map[int]bool
+	months   map[int]bool
+	weekdays map[int]bool
+	// Whether the day of month and day of week fields were restricted, which decides how they are combined.
+	daysRestricted     bool
+	weekdaysRestricted bool
+}
+
+// Helper function to parse a cron expression.
+// eg. "30 7 * * Mon-Fri" = 07:30 every weekday
+func parseCron(spec string) (cronExpression, error) {
+	var expr cronExpression
+
+	if descriptor, ok := cronDescriptors[strings.ToLower(strings.TrimSpace(spec))]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return expr, errors.Errorf("cron expression must have 5 fields: %s", spec)
+	}
+
+	var err error
+
+	if expr.minutes, _, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return expr, errors.Wrap(err, "invalid minute")
+	}
+
+	if expr.hours, _, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return expr, errors.Wrap(err, "invalid hour")
+	}
+
+	if expr.days, expr.daysRestricted, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return expr, errors.Wrap(err, "invalid day of month")
+	}
+
+	if expr.months, _, err = parseCronField(fields[3], 1, 12, cronMonths); err != nil {
+		return expr, errors.Wrap(err, "invalid month")
+	}
+
+	// Sunday can be declared as 0 or 7.
+	if expr.weekdays, expr.weekdaysRestricted, err = parseCronField(fields[4], 0, 7, cronWeekdays); err != nil {
+		return expr, errors.Wrap(err, "invalid day of week")
+	}
+
+	if expr.weekdays[7] {
+		expr.weekdays[0] = true
+	}
+
+	return expr, nil
+}
+
+// Helper function to parse a single field of a cron expression, which is a comma separated list of
+// values, ranges and steps. eg. "*/15", "1-5" or "mon,wed,fri"
+// Returns the matching values and whether the field was restricted (not "*").
+func parseCronField(field string, min, max int, names map[string]int) (map[int]bool, bool, error) {
+	values := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		var (
+			rng  = part
+			step = 1
+		)
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			rng = part[:i]
+
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, false, errors.Errorf("invalid step: %s", part)
+			}
+
+			step = n
+		}
+
+		start, end := min, max
+
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+
+			first, err := parseCronValue(bounds[0], min, max, names)
+			if err != nil {
+				return nil, false, err
+			}
+
+			start, end = first, first
+
+			// A value with a step runs until the end of the range eg. "5/15" = 5,20,35,50
+			if len(bounds) == 1 && step > 1 {
+				end = max
+			}
+
+			if len(bounds) == 2 {
+				if end, err = parseCronValue(bounds[1], min, max, names); err != nil {
+					return nil, false, err
+				}
+			}
+
+			if end < start {
+				return nil, false, errors.Errorf("invalid range: %s", rng)
+			}
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, field != "*", nil
+}
+
+// Helper function to parse a single value of a cron field, which can be a number or a name.
+func parseCronValue(value string, min, max int, names map[string]int) (int, error) {
+	if number, ok := names[strings.ToLower(value)]; ok {
+		return number, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Errorf("invalid value: %s", value)
+	}
+
+	if number < min || number > max {
+		return 0, errors.Errorf("value must be between %d and %d: %d", min, max, number)
+	}
+
+	return number, nil
+}
+
+// Helper function to determine if the expression matches the day of a time.
+// When both the day of month and day of week are restricted a day matches either of them, like cron.
+func (e cronExpression) matchesDay(t time.Time) bool {
+	var (
+		day     = e.days[t.Day()]
+		weekday = e.weekdays[int(t.Weekday())]
+	)
+
+	if e.daysRestricted && e.weekdaysRestricted {
+		return day || weekday
+	}
+
+	return day && weekday
+}
+
+// Helper function to find the next time the expression matches after a time, in the time's location.
+// Returns a zero time when the expression never matches.
+func (e cronExpression) next(after time.Time) time.Time {
+	var (
+		loc = after.Location()
+		t   = after.Truncate(time.Minute).Add(time.Minute)
+	)
+
+	for i := 0; i < cronMaxSteps; i++ {
+		if !e.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !e.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !e.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if !e.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,221 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+)
+
+// Helper function to load a time zone, failing the test when it isn't available.
+func loadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load time zone %s: %s", name, err)
+	}
+
+	return loc
+}
+
+func TestParseCron(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		err  bool
+	}{
+		{spec: "30 7 * * Mon-Fri"},
+		{spec: "*/15 * * * *"},
+		{spec: "5/15 * * * *"},
+		{spec: "0 9 1,15 jan-mar *"},
+		{spec: "0 9 * * 7"},
+		{spec: "@weekly"},
+		{spec: "@DAILY"},
+		{spec: "* * * *", err: true},
+		{spec: "60 * * * *", err: true},
+		{spec: "* 24 * * *", err: true},
+		{spec: "* * 0 * *", err: true},
+		{spec: "* * * 13 *", err: true},
+		{spec: "* * * * 8", err: true},
+		{spec: "*/0 * * * *", err: true},
+		{spec: "5-1 * * * *", err: true},
+		{spec: "* * * foo *", err: true},
+		{spec: "@fortnightly", err: true},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			_, err := parseCron(tc.spec)
+
+			if tc.err && err == nil {
+				t.Errorf("expected an error")
+			}
+
+			if !tc.err && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestParseCronField(t *testing.T) {
+	for _, tc := range []struct {
+		field      string
+		min, max   int
+		values     []int
+		restricted bool
+	}{
+		{field: "*", min: 0, max: 5, values: []int{0, 1, 2, 3, 4, 5}},
+		{field: "*/20", min: 0, max: 59, values: []int{0, 20, 40}, restricted: true},
+		{field: "5/15", min: 0, max: 59, values: []int{5, 20, 35, 50}, restricted: true},
+		{field: "10-20/5", min: 0, max: 59, values: []int{10, 15, 20}, restricted: true},
+		{field: "1,3,5", min: 0, max: 59, values: []int{1, 3, 5}, restricted: true},
+		{field: "mon-wed", min: 0, max: 7, values: []int{1, 2, 3}, restricted: true},
+	} {
+		t.Run(tc.field, func(t *testing.T) {
+			values, restricted, err := parseCronField(tc.field, tc.min, tc.max, cronWeekdays)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if restricted != tc.restricted {
+				t.Errorf("expected restricted %t, got %t", tc.restricted, restricted)
+			}
+
+			if len(values) != len(tc.values) {
+				t.Fatalf("expected values %v, got %v", tc.values, values)
+			}
+
+			for _, value := range tc.values {
+				if !values[value] {
+					t.Errorf("expected values %v, got %v", tc.values, values)
+				}
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	var (
+		sydney = loadLocation(t, "Australia/Sydney")
+		utc    = time.UTC
+	)
+
+	for _, tc := range []struct {
+		name  string
+		spec  string
+		after time.Time
+		next  time.Time
+	}{
+		{
+			name:  "weekday",
+			spec:  "30 7 * * Mon-Fri",
+			after: time.Date(2026, 10, 1, 8, 0, 0, 0, sydney),
+			next:  time.Date(2026, 10, 2, 7, 30, 0, 0, sydney),
+		},
+		{
+			// Clocks go forward on Sunday 4 October 2026, so Monday starts at UTC+11 instead of UTC+10.
+			name:  "weekday across daylight saving starting",
+			spec:  "30 7 * * Mon-Fri",
+			after: time.Date(2026, 10, 2, 8, 0, 0, 0, sydney),
+			next:  time.Date(2026, 10, 4, 20, 30, 0, 0, utc),
+		},
+		{
+			// Clocks go back on Sunday 5 April 2026, so Monday starts at UTC+10 instead of UTC+11.
+			name:  "weekday across daylight saving ending",
+			spec:  "30 7 * * Mon-Fri",
+			after: time.Date(2026, 4, 3, 8, 0, 0, 0, sydney),
+			next:  time.Date(2026, 4, 5, 21, 30, 0, 0, utc),
+		},
+		{
+			// 02:30 doesn't exist on the day clocks go forward, so the next match is the following day.
+			name:  "time skipped by daylight saving",
+			spec:  "30 2 * * *",
+			after: time.Date(2026, 10, 4, 0, 0, 0, 0, sydney),
+			next:  time.Date(2026, 10, 5, 2, 30, 0, 0, sydney),
+		},
+		{
+			name:  "exact match is not next",
+			spec:  "30 7 * * *",
+			after: time.Date(2026, 10, 1, 7, 30, 0, 0, utc),
+			next:  time.Date(2026, 10, 2, 7, 30, 0, 0, utc),
+		},
+		{
+			name:  "seconds are truncated",
+			spec:  "* * * * *",
+			after: time.Date(2026, 10, 1, 7, 30, 59, 0, utc),
+			next:  time.Date(2026, 10, 1, 7, 31, 0, 0, utc),
+		},
+		{
+			name:  "value with a step",
+			spec:  "5/15 * * * *",
+			after: time.Date(2026, 10, 1, 7, 21, 0, 0, utc),
+			next:  time.Date(2026, 10, 1, 7, 35, 0, 0, utc),
+		},
+		{
+			name:  "value with a step wraps to the next hour",
+			spec:  "5/15 * * * *",
+			after: time.Date(2026, 10, 1, 7, 50, 0, 0, utc),
+			next:  time.Date(2026, 10, 1, 8, 5, 0, 0, utc),
+		},
+		{
+			name:  "weekly is sunday at midnight",
+			spec:  "@weekly",
+			after: time.Date(2026, 10, 1, 7, 30, 0, 0, utc),
+			next:  time.Date(2026, 10, 4, 0, 0, 0, 0, utc),
+		},
+		{
+			name:  "day 7 is sunday",
+			spec:  "0 9 * * 7",
+			after: time.Date(2026, 10, 1, 7, 30, 0, 0, utc),
+			next:  time.Date(2026, 10, 4, 9, 0, 0, 0, utc),
+		},
+		{
+			name:  "day 0 is sunday",
+			spec:  "0 9 * * 0",
+			after: time.Date(2026, 10, 1, 7, 30, 0, 0, utc),
+			next:  time.Date(2026, 10, 4, 9, 0, 0, 0, utc),
+		},
+		{
+			// Friday 2 October comes before the 13th.
+			name:  "day of month or day of week",
+			spec:  "0 0 13 * Fri",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, utc),
+			next:  time.Date(2026, 10, 2, 0, 0, 0, 0, utc),
+		},
+		{
+			name:  "day of month or day of week matches the day of month",
+			spec:  "0 0 13 * Fri",
+			after: time.Date(2026, 10, 10, 0, 0, 0, 0, utc),
+			next:  time.Date(2026, 10, 13, 0, 0, 0, 0, utc),
+		},
+		{
+			name:  "day of month and every day of week",
+			spec:  "0 0 13 * *",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, utc),
+			next:  time.Date(2026, 10, 13, 0, 0, 0, 0, utc),
+		},
+		{
+			name:  "month wraps to the next year",
+			spec:  "0 0 1 jan *",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, utc),
+			next:  time.Date(2027, 1, 1, 0, 0, 0, 0, utc),
+		},
+		{
+			name:  "leap day",
+			spec:  "0 0 29 2 *",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, utc),
+			next:  time.Date(2028, 2, 29, 0, 0, 0, 0, utc),
+		},
+		{
+			name:  "never matches",
+			spec:  "0 0 30 2 *",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, utc),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parseCron(tc.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if next := expr.next(tc.after); !next.Equal(tc.next) {
+				t.Errorf("expected %s, got %s", tc.next, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package scaler
+
+import (
+	"time"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Helper function to build a demand source which counts CronJobs that are about to run, so nodes have booted by the time
+// their Jobs are created. Once a Job has been created it is counted by the Jobs source until it completes.
+func listCronJobs(lead time.Duration, loc *time.Location) func(k8s *kubernetes.Clientset, namespace string, opts metav1.ListOptions) ([]workload, error) {
+	return func(k8s *kubernetes.Clientset, namespace string, opts metav1.ListOptions) ([]workload, error) {
+		cronjobs, err := k8s.BatchV1beta1().CronJobs(namespace).List(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			workloads []workload
+			now       = time.Now()
+		)
+
+		for _, cronjob := range cronjobs.Items {
+			if !cronJobDue(cronjob, lead, now, loc) {
+				continue
+			}
+
+			workloads = append(workloads, workload{
+				Kind:        "CronJob",
+				Namespace:   cronjob.ObjectMeta.Namespace,
+				Name:        cronjob.ObjectMeta.Name,
+				Labels:      cronjob.ObjectMeta.Labels,
+				Annotations: cronjob.ObjectMeta.Annotations,
+				Replicas:    jobParallelism(cronjob.Spec.JobTemplate.Spec, 0),
+				Template:    cronjob.Spec.JobTemplate.Spec.Template,
+			})
+		}
+
+		return workloads, nil
+	}
+}
+
+// Helper function to determine if a CronJob will run within the lead time, or was due to run within the lead time
+// but its Job has not been created yet. The CronJob controller evaluates schedules in the local time zone of
+// kube-controller-manager, so they are evaluated in that location, or UTC when it isn't declared.
+func cronJobDue(cronjob batchv1beta1.CronJob, lead time.Duration, now time.Time, loc *time.Location) bool {
+	if cronjob.Spec.Suspend != nil && *cronjob.Spec.Suspend {
+		return false
+	}
+
+	expr, err := parseCron(cronjob.Spec.Schedule)
+	if err != nil {
+		return false
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	last := cronjob.Status.LastScheduleTime
+
+	for run := expr.next(now.Add(-lead).In(loc)); !run.IsZero() && !run.After(now.Add(lead)); run = expr.next(run) {
+		// The run is still to come.
+		if run.After(now) {
+			return true
+		}
+
+		// The run is due, but the Job won't be counted by the Jobs source until it has been created.
+		if last == nil || last.Time.Before(run) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCronJobDue(t *testing.T) {
+	var (
+		sydney    = loadLocation(t, "Australia/Sydney")
+		lead      = 10 * time.Minute
+		suspended = true
+	)
+
+	// Helper function to build a CronJob which was last scheduled at a time.
+	cronjob := func(schedule string, last *time.Time) batchv1beta1.CronJob {
+		c := batchv1beta1.CronJob{
+			Spec: batchv1beta1.CronJobSpec{
+				Schedule: schedule,
+			},
+		}
+
+		if last != nil {
+			scheduled := metav1.NewTime(*last)
+			c.Status.LastScheduleTime = &scheduled
+		}
+
+		return c
+	}
+
+	var (
+		before  = time.Date(2026, 10, 1, 8, 55, 0, 0, sydney)
+		after   = time.Date(2026, 10, 1, 9, 5, 0, 0, sydney)
+		run     = time.Date(2026, 10, 1, 9, 0, 0, 0, sydney)
+		created = cronjob("0 9 * * *", &run)
+	)
+
+	paused := cronjob("0 9 * * *", nil)
+	paused.Spec.Suspend = &suspended
+
+	for _, tc := range []struct {
+		name    string
+		cronjob batchv1beta1.CronJob
+		now     time.Time
+		loc     *time.Location
+		due     bool
+	}{
+		{"runs within the lead time", cronjob("0 9 * * *", nil), before, sydney, true},
+		{"evaluated in UTC by default", cronjob("0 9 * * *", nil), before, nil, false},
+		{"ran but the job wasn't created", cronjob("0 9 * * *", nil), after, sydney, true},
+		{"ran and the job was created", created, after, sydney, false},
+		{"suspended", paused, before, sydney, false},
+		{"invalid schedule", cronjob("0 9 * *", nil), before, sydney, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if due := cronJobDue(tc.cronjob, lead, tc.now, tc.loc); due != tc.due {
+				t.Errorf("expected due %t, got %t", tc.due, due)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package scaler
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SourceParams declares which workload kinds are counted towards capacity demand.
+type SourceParams struct {
+	// Deployments counts apps/v1 Deployments.
+	Deployments bool
+	// StatefulSets counts apps/v1 StatefulSets.
+	StatefulSets bool
+	// ReplicaSets counts apps/v1 ReplicaSets which are not owned by a Deployment.
+	ReplicaSets bool
+	// DaemonSets deducts the apps/v1 DaemonSet pods which run on every node from the node capacity.
+	DaemonSets bool
+	// Jobs counts batch/v1 Jobs which have not finished.
+	Jobs bool
+	// CronJobs counts batch/v1beta1 CronJobs which are scheduled to run within the CronJobLeadTime.
+	CronJobs bool
+	// CronJobLeadTime is how long before a CronJob is scheduled to run that its Jobs are counted.
+	CronJobLeadTime time.Duration
+	// CronJobLocation which CronJob schedules are evaluated in, the time zone of kube-controller-manager. Defaults to UTC.
+	CronJobLocation *time.Location
+}
+
+// FilterParams declares which workloads are counted towards capacity demand.
+type FilterParams struct {
+	// Namespaces to count workloads from, all namespaces are counted when empty.
+	Namespaces []string
+	// ExcludeNamespaces to never count workloads from.
+	ExcludeNamespaces []string
+	// Selector which the workload labels must match.
+	Selector string
+}
+
+// Helper function to determine if workloads in a namespace are counted.
+func (f FilterParams) allowsNamespace(namespace string) bool {
+	if len(f.Namespaces) > 0 && !contains(f.Namespaces, namespace) {
+		return false
+	}
+
+	return !contains(f.ExcludeNamespaces, namespace)
+}
+
+// Helper function to return the namespaces which need to be queried.
+func (f FilterParams) listNamespaces() []string {
+	if len(f.Namespaces) > 0 {
+		return f.Namespaces
+	}
+
+	return []string{corev1.NamespaceAll}
+}
+
+// workload is a set of identical pods which need to be scheduled on the cluster.
+type workload struct {
+	Kind        string
+	Namespace   string
+	Name        string
+	Labels      map[string]string
+	Annotations map[string]string
+	Replicas    int
+	Template    corev1.PodTemplateSpec
+}
+
+// demandSource lists the workloads of a single kind.
+type demandSource struct {
+	Kind string
+	List func(k8s *kubernetes.Clientset, namespace string, opts metav1.ListOptions) ([]workload, error)
+}
+
+// Helper function to return the demand sources which have been enabled.
+func (p SourceParams) sources() []demandSource {
+	var sources []demandSource
+
+	if p.Deployments {
+		sources = append(sources, demandSource{Kind: "Deployment", List: listDeployments})
+	}
+
+	if p.StatefulSets {
+		sources = append(sources, demandSource{Kind: "StatefulSet", List: listStatefulSets})
+	}
+
+	if p.ReplicaSets {
+		sources = append(sources, demandSource{Kind: "ReplicaSet", List: listReplicaSets})
+	}
+
+	if p.Jobs {
+		sources = append(sources, demandSource{Kind: "Job", List: listJobs})
+	}
+
+	if p.CronJobs {
+		sources = append(sources, demandSource{Kind: "CronJob", List: listCronJobs(p.CronJobLeadTime, p.CronJobLocation)})
+	}
+
+	return sources
+}
+
+// Helper function to list the workloads from all the enabled demand sources which match the filters.
+func listWorkloads(k8s *kubernetes.Clientset, params SourceParams, filter FilterParams) ([]workload, error) {
+	var (
+		workloads []workload
+		opts      = metav1.ListOptions{LabelSelector: filter.Selector}
+	)
+
+	for _, source := range params.sources() {
+		for _, namespace := range filter.listNamespaces() {
+			list, err := source.List(k8s, namespace, opts)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to list %ss", source.Kind)
+			}
+
+			for _, workload := range list {
+				if !filter.allowsNamespace(workload.Namespace) {
+					continue
+				}
+
+				if workload.Annotations[AnnotationIgnore] == "true" {
+					continue
+				}
+
+				workloads = append(workloads, workload)
+			}
+		}
+	}
+
+	return workloads, nil
+}
+
+func listDeployments(k8s *kubernetes.Clientset, namespace string, opts metav1.ListOptions) ([]workload, error) {
+	deployments, err := k8s.AppsV1().Deployments(namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var workloads []workload
+
+	for _, deployment := range deployments.Items {
+		workloads = append(workloads, workload{
+			Kind:        "Deployment",
+			Namespace:   deployment.ObjectMeta.Namespace,
+			Name:        deployment.ObjectMeta.Name,
+			Labels:      deployment.ObjectMeta.Labels,
+			Annotations: deployment.ObjectMeta.Annotations,
+			Replicas:    replicas(deployment.Spec.Replicas),
+			Template:    deployment.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+func listStatefulSets(k8s *kubernetes.Clientset, namespace string, opts metav1.ListOptions) ([]workload, error) {
+	sets, err := k8s.AppsV1().StatefulSets(namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var workloads []workload
+
+	for _, set := range sets.Items {
+		workloads = append(workloads, workload{
+			Kind:        "StatefulSet",
+			Namespace:   set.ObjectMeta.Namespace,
+			Name:        set.ObjectMeta.Name,
+			Labels:      set.ObjectMeta.Labels,
+			Annotations: set.ObjectMeta.Annotations,
+			Replicas:    replicas(set.Spec.Replicas),
+			Template:    set.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+func listReplicaSets(k8s *kubernetes.Clientset, namespace string, opts metav1.ListOptions) ([]workload, error) {
+	sets, err := k8s.AppsV1().ReplicaSets(namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var workloads []workload
+
+	for _, set := range sets.Items {
+		// ReplicaSets managed by a Deployment are already counted by the Deployment itself.
+		if ownedBy(set.ObjectMeta, "Deployment") {
+			continue
+		}
+
+		workloads = append(workloads, workload{
+			Kind:        "ReplicaSet",
+			Namespace:   set.ObjectMeta.Namespace,
+			Name:        set.ObjectMeta.Name,
+			Labels:      set.ObjectMeta.Labels,
+			Annotations: set.ObjectMeta.Annotations,
+			Replicas:    replicas(set.Spec.Replicas),
+			Template:    set.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+func listJobs(k8s *kubernetes.Clientset, namespace string, opts metav1.ListOptions) ([]workload, error) {
+	jobs, err := k8s.BatchV1().Jobs(namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var workloads []workload
+
+	for _, job := range jobs.Items {
+		if jobFinished(job) {
+			continue
+		}
+
+		workloads = append(workloads, workload{
+			Kind:        "Job",
+			Namespace:   job.ObjectMeta.Namespace,
+			Name:        job.ObjectMeta.Name,
+			Labels:      job.ObjectMeta.Labels,
+			Annotations: job.ObjectMeta.Annotations,
+			Replicas:    jobParallelism(job.Spec, job.Status.Succeeded),
+			Template:    job.Spec.Template,
+		})
+	}
+
+	return workloads, nil
+}
+
+// Helper function to return the number of replicas, which defaults to 1 when not declared.
+func replicas(declared *int32) int {
+	if declared == nil {
+		return 1
+	}
+
+	return int(*declared)
+}
+
+// Helper function to determine if an object is controlled by a specific kind of object.
+func ownedBy(meta metav1.ObjectMeta, kind string) bool {
+	for _, ref := range meta.OwnerReferences {
+		if ref.Kind == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper function to determine if a Job has completed or failed.
+func jobFinished(job batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		if condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper function to determine how many pods a Job runs at once.
+// This is the parallelism, capped by the number of completions still outstanding.
+func jobParallelism(spec batchv1.JobSpec, succeeded int32) int {
+	parallelism := replicas(spec.Parallelism)
+
+	if spec.Completions != nil {
+		remaining := int(*spec.Completions - succeeded)
+
+		if remaining < parallelism {
+			parallelism = remaining
+		}
+	}
+
+	if parallelism < 0 {
+		return 0
+	}
+
+	return parallelism
+}
+
+// Helper function to expand a workload into the resources requested by each of its pods.
+func (w workload) pods() []resources {
+	var (
+		cpu, mem = podRequests(w.Template.Spec)
+		pods     = make([]resources, w.Replicas)
+	)
+
+	for i := range pods {
+		pods[i] = resources{CPU: cpu, Memory: mem, Pods: 1}
+	}
+
+	return pods
+}
+
+// Helper function to calculate how much CPU (millicores) + Memory (megabytes) a single pod requests.
+func podRequests(spec corev1.PodSpec) (int, int) {
+	var (
+		cpu int
+		mem int
+	)
+
+	for _, container := range spec.Containers {
+		reqCPU := container.Resources.Requests[corev1.ResourceCPU]
+		reqMem := container.Resources.Requests[corev1.ResourceMemory]
+
+		cpu = cpu + int(reqCPU.MilliValue())
+		mem = mem + int(reqMem.Value()/1024.0/1024.0)
+	}
+
+	// Init containers run one at a time before the app containers, so the pod
+	// needs at least as much as its largest init container.
+	for _, container := range spec.InitContainers {
+		reqCPU := container.Resources.Requests[corev1.ResourceCPU]
+		reqMem := container.Resources.Requests[corev1.ResourceMemory]
+
+		if int(reqCPU.MilliValue()) > cpu {
+			cpu = int(reqCPU.MilliValue())
+		}
+
+		if int(reqMem.Value()/1024.0/1024.0) > mem {
+			mem = int(reqMem.Value() / 1024.0 / 1024.0)
+		}
+	}
+
+	return cpu, mem
+}
@@ -0,0 +1,143 @@
+package scaler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+// TagClusterPrefix is the tag Kubernetes uses to declare which cluster a resource belongs to.
+// eg. kubernetes.io/cluster/production=owned
+const TagClusterPrefix = "kubernetes.io/cluster/"
+
+// Helper function to build the tags an autoscaling group must have to be discovered.
+// Tags are declared as "key" (any value) or "key=value".
+func discoveryTags(list []string, cluster string) map[string]string {
+	tags := make(map[string]string)
+
+	for _, tag := range list {
+		kv := strings.SplitN(tag, "=", 2)
+
+		if len(kv) == 2 {
+			tags[kv[0]] = kv[1]
+		} else {
+			tags[kv[0]] = ""
+		}
+	}
+
+	if cluster != "" {
+		tags[TagClusterPrefix+cluster] = ""
+	}
+
+	return tags
+}
+
+// Helper function to determine if an autoscaling group has all the tags.
+// Tags without a value only need to exist on the group.
+func hasTags(asg *autoscaling.Group, tags map[string]string) bool {
+	values := groupTags(asg)
+
+	for key, value := range tags {
+		actual, ok := values[key]
+		if !ok {
+			return false
+		}
+
+		if value != "" && value != actual {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Helper function to convert the tags of an autoscaling group into a map.
+func groupTags(asg *autoscaling.Group) map[string]string {
+	values := make(map[string]string)
+
+	for _, tag := range asg.Tags {
+		values[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	return values
+}
+
+// Helper function to lookup the autoscaling groups which are managed by the scaler.
+// Declared groups are always managed, followed by any other groups which have all the discovery tags.
+func discoverGroups(ctx context.Context, w io.Writer, svc *autoscaling.AutoScaling, params WatchParams, tags map[string]string) ([]*group, error) {
+	var (
+		asgs  = make(map[string]*autoscaling.Group)
+		input = &autoscaling.DescribeAutoScalingGroupsInput{
+			MaxRecords: aws.Int64(100),
+		}
+	)
+
+	// Without discovery tags we only need to query the declared groups.
+	if len(tags) == 0 {
+		for _, group := range params.Groups {
+			input.AutoScalingGroupNames = append(input.AutoScalingGroupNames, aws.String(group.Name))
+		}
+	}
+
+	err := svc.DescribeAutoScalingGroupsPagesWithContext(ctx, input, func(page *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+		for _, asg := range page.AutoScalingGroups {
+			asgs[aws.StringValue(asg.AutoScalingGroupName)] = asg
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		groups   []*group
+		declared = make(map[string]bool)
+	)
+
+	for _, declaredGroup := range params.Groups {
+		asg, ok := asgs[declaredGroup.Name]
+		if !ok {
+			fmt.Fprintf(w, "WARNING: Failed to lookup the ASG: %s\n", declaredGroup.Name)
+			continue
+		}
+
+		declared[declaredGroup.Name] = true
+
+		groups = append(groups, &group{
+			Name:   declaredGroup.Name,
+			Config: resolveGroupConfig(w, params, declaredGroup, asg),
+			ASG:    asg,
+		})
+	}
+
+	if len(tags) == 0 {
+		return groups, nil
+	}
+
+	var discovered []string
+
+	for name, asg := range asgs {
+		if !declared[name] && hasTags(asg, tags) {
+			discovered = append(discovered, name)
+		}
+	}
+
+	// Sorted so the order in which demand is assigned to groups is stable between cycles.
+	sort.Strings(discovered)
+
+	for _, name := range discovered {
+		groups = append(groups, &group{
+			Name:   name,
+			Config: resolveGroupConfig(w, params, GroupParams{Name: name}, asgs[name]),
+			ASG:    asgs[name],
+		})
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,208 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// TaintScaleDown is added to nodes which are being drained before they are removed from the group.
+const TaintScaleDown = "autoscaler.previousnext/scale-down"
+
+const (
+	// How many times to retry updating a node which was modified since we fetched it.
+	updateNodeRetries = 5
+	// How long to wait before retrying an eviction which was blocked by a PodDisruptionBudget.
+	evictionRetryInterval = 5 * time.Second
+	// How often to check if evicted pods have been deleted.
+	deletionPollInterval = 2 * time.Second
+)
+
+// Helper function to cordon and taint a node so no new pods are scheduled onto it.
+func cordonNode(k8s *kubernetes.Clientset, name string) error {
+	return updateNode(k8s, name, func(node *corev1.Node) {
+		node.Spec.Unschedulable = true
+
+		if !hasTaint(node.Spec.Taints, TaintScaleDown) {
+			node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{
+				Key:    TaintScaleDown,
+				Value:  "true",
+				Effect: corev1.TaintEffectNoSchedule,
+			})
+		}
+	})
+}
+
+// Helper function to undo cordonNode, allowing pods to be scheduled onto the node again.
+func uncordonNode(k8s *kubernetes.Clientset, name string) error {
+	return updateNode(k8s, name, func(node *corev1.Node) {
+		node.Spec.Unschedulable = false
+
+		var taints []corev1.Taint
+
+		for _, taint := range node.Spec.Taints {
+			if taint.Key != TaintScaleDown {
+				taints = append(taints, taint)
+			}
+		}
+
+		node.Spec.Taints = taints
+	})
+}
+
+// Helper function to modify a node, retrying when it was modified since we fetched it eg. by the kubelet.
+func updateNode(k8s *kubernetes.Clientset, name string, mutate func(node *corev1.Node)) error {
+	for i := 0; ; i++ {
+		node, err := k8s.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		mutate(node)
+
+		_, err = k8s.CoreV1().Nodes().Update(node)
+		if apierrors.IsConflict(err) && i < updateNodeRetries {
+			continue
+		}
+
+		return err
+	}
+}
+
+// Helper function to determine if a list of taints contains a key.
+func hasTaint(taints []corev1.Taint, key string) bool {
+	for _, taint := range taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper function to list the pods which are running on a node.
+func podsOnNode(k8s *kubernetes.Clientset, name string) ([]corev1.Pod, error) {
+	pods, err := k8s.CoreV1().Pods(corev1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pods.Items, nil
+}
+
+// Helper function to determine if a pod needs to be evicted before its node is removed.
+// DaemonSet and static (mirror) pods are managed by the node itself, and finished pods don't need to move.
+func needsEviction(pod corev1.Pod) bool {
+	if ownedBy(pod.ObjectMeta, "DaemonSet") {
+		return false
+	}
+
+	if _, ok := pod.ObjectMeta.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// Helper function to evict all the pods from a node using the Eviction API, which respects PodDisruptionBudgets.
+// Waits until the pods have terminated, allowing at least each pod's termination grace period.
+func drainNode(w io.Writer, k8s *kubernetes.Clientset, name string, timeout time.Duration) error {
+	pods, err := podsOnNode(k8s, name)
+	if err != nil {
+		return errors.Wrap(err, "failed to list pods")
+	}
+
+	var evict []corev1.Pod
+
+	for _, pod := range pods {
+		if needsEviction(pod) {
+			evict = append(evict, pod)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for _, pod := range evict {
+		fmt.Fprintf(w, "Evicting pod %s/%s from node %s\n", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, name)
+
+		if err := evictPod(k8s, pod, deadline); err != nil {
+			return errors.Wrapf(err, "failed to evict pod %s/%s", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
+		}
+
+		// Pods which take longer to shutdown than the drain timeout are given their full grace period.
+		if pod.Spec.TerminationGracePeriodSeconds != nil {
+			graceful := time.Now().Add(time.Duration(*pod.Spec.TerminationGracePeriodSeconds) * time.Second)
+
+			if graceful.After(deadline) {
+				deadline = graceful
+			}
+		}
+	}
+
+	return waitForDeletion(k8s, evict, deadline)
+}
+
+// Helper function to evict a pod, retrying while a PodDisruptionBudget blocks the eviction.
+func evictPod(k8s *kubernetes.Clientset, pod corev1.Pod, deadline time.Time) error {
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pod.ObjectMeta.Namespace,
+			Name:      pod.ObjectMeta.Name,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: pod.Spec.TerminationGracePeriodSeconds,
+		},
+	}
+
+	for {
+		err := k8s.CoreV1().Pods(pod.ObjectMeta.Namespace).Evict(eviction)
+		if err == nil || apierrors.IsNotFound(err) {
+			return nil
+		}
+
+		// The API responds with "429 Too Many Requests" when the eviction would violate a PodDisruptionBudget.
+		if !apierrors.IsTooManyRequests(err) {
+			return err
+		}
+
+		if time.Now().Add(evictionRetryInterval).After(deadline) {
+			return errors.Wrap(err, "timed out waiting for PodDisruptionBudget")
+		}
+
+		time.Sleep(evictionRetryInterval)
+	}
+}
+
+// Helper function to wait until all the evicted pods have been deleted.
+func waitForDeletion(k8s *kubernetes.Clientset, pods []corev1.Pod, deadline time.Time) error {
+	return wait.PollImmediate(deletionPollInterval, time.Until(deadline), func() (bool, error) {
+		for _, pod := range pods {
+			current, err := k8s.CoreV1().Pods(pod.ObjectMeta.Namespace).Get(pod.ObjectMeta.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			if err != nil {
+				return false, err
+			}
+
+			// A pod with the same name but a different UID is a replacement eg. from a StatefulSet.
+			if current.ObjectMeta.UID == pod.ObjectMeta.UID {
+				return false, nil
+			}
+		}
+
+		return true, nil
+	})
+}
@@ -0,0 +1,162 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"github.com/pkg/errors"
+)
+
+// DefaultHistoryPath is the file which demand is recorded to when predictive scaling is enabled.
+const DefaultHistoryPath = "/var/lib/k8s-aws-autoscaler/history.jsonl"
+
+// Seasonal patterns which demand is forecast from: the same time on the previous day and in the previous week.
+var seasonalLags = []time.Duration{
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+}
+
+// PredictiveParams declares how demand is forecast from the recorded history.
+type PredictiveParams struct {
+	// Enabled adds the forecast demand as a floor to the desired capacity.
+	Enabled bool
+	// HistoryPath is the file which demand is recorded to.
+	HistoryPath string
+	// Horizon which demand is forecast for.
+	Horizon time.Duration
+	// Step between the points which are forecast within the horizon.
+	Step time.Duration
+	// Margin is a percentage which is added to the forecast demand.
+	Margin float64
+}
+
+// Helper function to determine how long samples need to be kept to forecast the horizon.
+func (p PredictiveParams) retention() time.Duration {
+	return seasonalLags[len(seasonalLags)-1] + p.Horizon + p.Step
+}
+
+// Helper function to forecast the demand of a group at a time, from the demand recorded in each seasonal pattern.
+// Returns false when there is no history for the time.
+func (p PredictiveParams) predict(h *history, group string, at time.Time) (resources, bool) {
+	var (
+		total resources
+		count int
+	)
+
+	for _, lag := range seasonalLags {
+		peak, ok := h.peak(group, at.Add(-lag), at.Add(-lag).Add(p.Step))
+		if !ok {
+			continue
+		}
+
+		total = total.add(peak)
+		count++
+	}
+
+	if count == 0 {
+		return resources{}, false
+	}
+
+	scale := (1 + p.Margin/100) / float64(count)
+
+	return resources{
+		CPU:    int(math.Ceil(float64(total.CPU) * scale)),
+		Memory: int(math.Ceil(float64(total.Memory) * scale)),
+		Pods:   int(math.Ceil(float64(total.Pods) * scale)),
+	}, true
+}
+
+// Helper function to forecast the highest demand of a group within the horizon.
+func (p PredictiveParams) forecast(h *history, group string, now time.Time) (resources, bool) {
+	var (
+		peak  resources
+		found bool
+	)
+
+	for at := now; at.Before(now.Add(p.Horizon)); at = at.Add(p.Step) {
+		if demand, ok := p.predict(h, group, at); ok {
+			peak = peak.max(demand)
+			found = true
+		}
+	}
+
+	return peak, found
+}
+
+// Helper function to determine how many nodes are required to run the forecast demand.
+func forecastNodes(demand, node resources) int64 {
+	nodes := getDesiredAggregate(demand.CPU, demand.Memory, node.CPU, node.Memory)
+
+	if byPods := int64(divideRoundUp(demand.Pods, node.Pods)); byPods > nodes {
+		nodes = byPods
+	}
+
+	return nodes
+}
+
+// ForecastParams passed to the Forecast function.
+type ForecastParams struct {
+	// Predictive declares how demand is forecast.
+	Predictive PredictiveParams
+	// Group to print the forecast for, all groups with history when empty.
+	Group string
+	// Lookback is how much of the recorded history to print before the forecast.
+	Lookback time.Duration
+}
+
+// Forecast prints the recorded demand of each group, followed by the forecast demand.
+func Forecast(w io.Writer, params ForecastParams) error {
+	if params.Predictive.Step <= 0 {
+		return errors.Errorf("step must be greater than zero: %s", params.Predictive.Step)
+	}
+
+	h, err := openHistory(params.Predictive.HistoryPath, params.Predictive.retention())
+	if err != nil {
+		return errors.Wrap(err, "failed to open history")
+	}
+
+	groups := h.groups()
+
+	if params.Group != "" {
+		groups = []string{params.Group}
+	}
+
+	var (
+		now   = time.Now().Truncate(params.Predictive.Step)
+		start = now.Add(-params.Lookback)
+		end   = now.Add(params.Predictive.Horizon)
+	)
+
+	for _, group := range groups {
+		fmt.Fprintf(w, "Group: %s\n", group)
+
+		table := uitable.New()
+		table.AddRow("TIME", "ACTUAL CPU", "ACTUAL MEMORY", "ACTUAL PODS", "FORECAST CPU", "FORECAST MEMORY", "FORECAST PODS")
+
+		for at := start; at.Before(end); at = at.Add(params.Predictive.Step) {
+			row := []interface{}{at.Format("2006-01-02 15:04")}
+
+			if actual, ok := h.peak(group, at, at.Add(params.Predictive.Step)); ok {
+				row = append(row, actual.CPU, actual.Memory, actual.Pods)
+			} else {
+				row = append(row, "-", "-", "-")
+			}
+
+			if forecast, ok := params.Predictive.predict(h, group, at); ok {
+				row = append(row, forecast.CPU, forecast.Memory, forecast.Pods)
+			} else {
+				row = append(row, "-", "-", "-")
+			}
+
+			table.AddRow(row...)
+		}
+
+		fmt.Fprintln(w, table)
+		fmt.Fprintln(w)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPredict(t *testing.T) {
+	var (
+		now = time.Date(2026, 10, 8, 12, 0, 0, 0, time.UTC)
+		day = now.Add(-24 * time.Hour)
+		// The same time in the previous week.
+		week = now.Add(-7 * 24 * time.Hour)
+		h    = &history{
+			samples: map[string][]sample{
+				"both": {
+					{Time: week, CPU: 300, Memory: 600, Pods: 3},
+					{Time: day, CPU: 100, Memory: 200, Pods: 1},
+					// The peak within the step is used.
+					{Time: day.Add(30 * time.Minute), CPU: 101, Memory: 200, Pods: 1},
+				},
+				"day": {
+					{Time: day, CPU: 100, Memory: 200, Pods: 1},
+				},
+				"stale": {
+					{Time: day.Add(-time.Hour), CPU: 100, Memory: 200, Pods: 1},
+				},
+			},
+		}
+	)
+
+	for _, tc := range []struct {
+		name     string
+		group    string
+		margin   float64
+		expected resources
+		found    bool
+	}{
+		{"average of the day and week", "both", 0, resources{CPU: 201, Memory: 400, Pods: 2}, true},
+		{"margin is added", "both", 50, resources{CPU: 301, Memory: 600, Pods: 3}, true},
+		{"only the day", "day", 0, resources{CPU: 100, Memory: 200, Pods: 1}, true},
+		{"nothing within the step", "stale", 0, resources{}, false},
+		{"no history", "api", 0, resources{}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			params := PredictiveParams{
+				Step:   time.Hour,
+				Margin: tc.margin,
+			}
+
+			demand, found := params.predict(h, tc.group, now)
+
+			if found != tc.found {
+				t.Errorf("expected found %t, got %t", tc.found, found)
+			}
+
+			if demand != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, demand)
+			}
+		})
+	}
+}
+
+func TestForecast(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 8, 12, 0, 0, 0, time.UTC)
+		day    = now.Add(-24 * time.Hour)
+		params = PredictiveParams{
+			Horizon: 3 * time.Hour,
+			Step:    time.Hour,
+		}
+		h = &history{
+			samples: map[string][]sample{
+				"web": {
+					{Time: day, CPU: 100, Memory: 500, Pods: 1},
+					{Time: day.Add(2 * time.Hour), CPU: 400, Memory: 100, Pods: 2},
+					// Beyond the horizon.
+					{Time: day.Add(3 * time.Hour), CPU: 900, Memory: 900, Pods: 9},
+				},
+			},
+		}
+	)
+
+	demand, found := params.forecast(h, "web", now)
+	if !found {
+		t.Fatalf("expected a forecast")
+	}
+
+	if expected := (resources{CPU: 400, Memory: 500, Pods: 2}); demand != expected {
+		t.Errorf("expected %+v, got %+v", expected, demand)
+	}
+
+	if _, found := params.forecast(h, "web", now.Add(-48*time.Hour)); found {
+		t.Errorf("expected no forecast without history")
+	}
+}
+
+func TestForecastNodes(t *testing.T) {
+	node := resources{CPU: 1000, Memory: 1000, Pods: 10}
+
+	for _, tc := range []struct {
+		name   string
+		demand resources
+		nodes  int64
+	}{
+		{"nothing", resources{}, 0},
+		{"cpu bound", resources{CPU: 2500, Memory: 1000, Pods: 5}, 3},
+		{"memory bound", resources{CPU: 1000, Memory: 3500, Pods: 5}, 4},
+		{"pods bound", resources{CPU: 100, Memory: 100, Pods: 25}, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if nodes := forecastNodes(tc.demand, node); nodes != tc.nodes {
+				t.Errorf("expected %d nodes, got %d", tc.nodes, nodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// TagConfigPrefix declares an option for the group on the autoscaling group.
+// eg. k8s-aws-autoscaler/node-cpu=3800
+const TagConfigPrefix = "k8s-aws-autoscaler/"
+
+// GroupParams declares an autoscaling group which is managed by the scaler.
+type GroupParams struct {
+	// Name of the autoscaling group.
+	Name string
+	// Options for the group which override the WatchParams defaults.
+	Options map[string]string
+}
+
+// ParseGroup parses a group declared as a name followed by semicolon separated options.
+// eg. nodes-web;node-cpu=3800;node-mem=15000;min=1;max=10;namespaces=web,api;selector=tier=web
+func ParseGroup(spec string) (GroupParams, error) {
+	var (
+		parts  = strings.Split(spec, ";")
+		params = GroupParams{
+			Name:    strings.TrimSpace(parts[0]),
+			Options: make(map[string]string),
+		}
+	)
+
+	if params.Name == "" {
+		return params, errors.Errorf("group name not declared: %s", spec)
+	}
+
+	var config groupConfig
+
+	for _, part := range parts[1:] {
+		option := strings.SplitN(part, "=", 2)
+		if len(option) != 2 {
+			return params, errors.Errorf("group option must be declared as key=value: %s", part)
+		}
+
+		key, value := strings.TrimSpace(option[0]), strings.TrimSpace(option[1])
+
+		// Options are validated up front, so they can be applied every cycle without failing.
+		if err := config.set(key, value); err != nil {
+			return params, errors.Wrapf(err, "failed to parse group %s", params.Name)
+		}
+
+		params.Options[key] = value
+	}
+
+	return params, nil
+}
+
+// groupConfig is the configuration of a group after the defaults, declared options and tags have been applied.
+type groupConfig struct {
+	// NodeCPU declare how much CPU a node has, used until a node has registered with the cluster.
+	NodeCPU int
+	// NodeMemory declare how much memory a node has, used until a node has registered with the cluster.
+	NodeMemory int
+	// NodePods declare how many pods a node can run, used until a node has registered with the cluster.
+	NodePods int
+	// MinSize raises the minimum size of the autoscaling group.
+	MinSize *int64
+	// MaxSize lowers the maximum size of the autoscaling group.
+	MaxSize *int64
+	// Headroom which is added on top of the demand.
+	Headroom HeadroomParams
+	// Cooldown between scaling events.
+	Cooldown CooldownParams
+	// Stabilization of the desired capacity.
+	Stabilization StabilizationParams
+	// Filter the workloads which are counted towards the group, on top of the WatchParams filter.
+	Filter FilterParams
+}
+
+// Helper function to build the configuration of a group.
+// The WatchParams defaults are overridden by the options declared for the group, which are
+// overridden by the group's tags. Malformed tags are reported and ignored.
+func resolveGroupConfig(w io.Writer, params WatchParams, declared GroupParams, asg *autoscaling.Group) groupConfig {
+	config := groupConfig{
+		NodeCPU:       params.NodeCPU,
+		NodeMemory:    params.NodeMemory,
+		NodePods:      params.NodePods,
+		Headroom:      params.Headroom,
+		Cooldown:      params.Cooldown,
+		Stabilization: params.Stabilization,
+	}
+
+	for key, value := range declared.Options {
+		if err := config.set(key, value); err != nil {
+			fmt.Fprintf(w, "WARNING: Ignoring invalid option %s=%s for group %s: %s\n", key, value, declared.Name, err)
+		}
+	}
+
+	for _, tag := range asg.Tags {
+		key := aws.StringValue(tag.Key)
+
+		if !strings.HasPrefix(key, TagConfigPrefix) {
+			continue
+		}
+
+		if err := config.set(strings.TrimPrefix(key, TagConfigPrefix), aws.StringValue(tag.Value)); err != nil {
+			fmt.Fprintf(w, "WARNING: Ignoring malformed tag %s=%s on group %s: %s\n", key, aws.StringValue(tag.Value), declared.Name, err)
+		}
+	}
+
+	if config.MinSize != nil && config.MaxSize != nil && *config.MinSize > *config.MaxSize {
+		fmt.Fprintf(w, "WARNING: Ignoring min (%d) and max (%d) for group %s because: min is greater than max\n", *config.MinSize, *config.MaxSize, declared.Name)
+
+		config.MinSize = nil
+		config.MaxSize = nil
+	}
+
+	return config
+}
+
+// Helper function to set a single group option.
+// A malformed value leaves the current setting in place.
+func (c *groupConfig) set(key, value string) error {
+	var (
+		next = *c
+		err  error
+	)
+
+	switch key {
+	case "node-cpu":
+		next.NodeCPU, err = parsePositive(value)
+	case "node-mem":
+		next.NodeMemory, err = parsePositive(value)
+	case "node-pods":
+		next.NodePods, err = parsePositive(value)
+	case "min":
+		next.MinSize, err = parseSize(value)
+	case "max":
+		next.MaxSize, err = parseSize(value)
+	case "headroom-nodes":
+		next.Headroom.Nodes, err = parseNonNegative(value)
+	case "headroom-percent":
+		next.Headroom.Percent, err = parseFloat(value)
+	case "headroom-cpu":
+		next.Headroom.CPU, err = parseNonNegative(value)
+	case "headroom-mem":
+		next.Headroom.Memory, err = parseNonNegative(value)
+	case "scale-up-cooldown":
+		next.Cooldown.Up, err = parseDuration(value)
+	case "scale-down-cooldown":
+		next.Cooldown.Down, err = parseDuration(value)
+	case "scale-down-delay-after-up":
+		next.Cooldown.DownAfterUp, err = parseDuration(value)
+	case "scale-up-stabilization":
+		next.Stabilization.UpWindow, err = parseDuration(value)
+	case "scale-down-stabilization":
+		next.Stabilization.DownWindow, err = parseDuration(value)
+	case "scale-up-max-nodes":
+		next.Stabilization.UpMaxNodes, err = parseNonNegative(value)
+	case "scale-up-max-percent":
+		next.Stabilization.UpMaxPercent, err = parseFloat(value)
+	case "scale-up-period":
+		next.Stabilization.UpPeriod, err = parseDuration(value)
+	case "namespaces":
+		next.Filter.Namespaces = splitList(value)
+	case "exclude-namespaces":
+		next.Filter.ExcludeNamespaces = splitList(value)
+	case "selector":
+		_, err = labels.Parse(value)
+		next.Filter.Selector = value
+	default:
+		return errors.Errorf("unknown option: %s", key)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s", key)
+	}
+
+	*c = next
+
+	return nil
+}
+
+// Helper function to parse a number which must be greater than zero.
+func parsePositive(value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if number <= 0 {
+		return 0, errors.Errorf("must be greater than zero: %d", number)
+	}
+
+	return number, nil
+}
+
+// Helper function to parse a number which must not be negative.
+func parseNonNegative(value string) (int, error) {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if number < 0 {
+		return 0, errors.Errorf("must not be negative: %d", number)
+	}
+
+	return number, nil
+}
+
+// Helper function to parse a decimal number which must not be negative.
+func parseFloat(value string) (float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if number < 0 {
+		return 0, errors.Errorf("must not be negative: %v", number)
+	}
+
+	return number, nil
+}
+
+// Helper function to parse a duration which must not be negative.
+func parseDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if duration < 0 {
+		return 0, errors.Errorf("must not be negative: %s", duration)
+	}
+
+	return duration, nil
+}
+
+// Helper function to parse a group size.
+func parseSize(value string) (*int64, error) {
+	size, err := parseNonNegative(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return aws.Int64(int64(size)), nil
+}
+
+// Helper function to split a comma separated list.
+func splitList(value string) []string {
+	var list []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// Helper function to determine if a workload or pod matches the filter.
+func (f FilterParams) matches(namespace string, set map[string]string) bool {
+	if !f.allowsNamespace(namespace) {
+		return false
+	}
+
+	selector, err := labels.Parse(f.Selector)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(set))
+}
+
+// group is an autoscaling group being evaluated during a single cycle.
+type group struct {
+	Name   string
+	Config groupConfig
+	ASG    *autoscaling.Group
+	// Nodes registered with the cluster which belong to the group.
+	Nodes []corev1.Node
+	// Templates of the group's nodes which pods are scheduled against.
+	Templates []nodeTemplate
+	// Node is the capacity of each node which is available for workloads.
+	Node resources
+	// Pods which have been assigned to the group.
+	Pods []resources
+	// Pending pods which are unschedulable and have been assigned to the group.
+	Pending []resources
+	// Candidates for removal, which are described the first time they are needed.
+	Candidates []candidate
+}
+
+// Helper function to determine the minimum and maximum size of the group.
+// The declared sizes can only narrow the autoscaling group's own sizes, since AWS rejects a desired capacity outside of them.
+func (g *group) bounds() (int64, int64) {
+	var (
+		min = *g.ASG.MinSize
+		max = *g.ASG.MaxSize
+	)
+
+	if g.Config.MinSize != nil && *g.Config.MinSize > min {
+		min = *g.Config.MinSize
+
+		if min > *g.ASG.MaxSize {
+			min = *g.ASG.MaxSize
+		}
+	}
+
+	if g.Config.MaxSize != nil && *g.Config.MaxSize < max {
+		max = *g.Config.MaxSize
+
+		if max < *g.ASG.MinSize {
+			max = *g.ASG.MinSize
+		}
+	}
+
+	return min, max
+}
+
+// Helper function to determine if a pod could run on the group.
+func (g *group) accepts(namespace string, set map[string]string, spec corev1.PodSpec) bool {
+	return g.Config.Filter.matches(namespace, set) && schedulableOnAny(g.Templates, spec)
+}
+
+// Helper function to assign each workload to the first group which can run it, so that no pod is counted twice.
+func assignWorkloads(w io.Writer, groups []*group, workloads []workload) {
+	for _, workload := range workloads {
+		assigned := false
+
+		for _, g := range groups {
+			if !g.accepts(workload.Namespace, workload.Labels, workload.Template.Spec) {
+				continue
+			}
+
+			g.Pods = append(g.Pods, workload.pods()...)
+			assigned = true
+
+			break
+		}
+
+		if !assigned {
+			fmt.Fprintf(w, "Ignoring %s %s/%s because: it can't be scheduled on any group\n", workload.Kind, workload.Namespace, workload.Name)
+		}
+	}
+}
+
+// Helper function to assign each unschedulable pod to the first group which can run it, so that no pod is counted twice.
+func assignPending(w io.Writer, groups []*group, pods []corev1.Pod) {
+	for _, pod := range pods {
+		var (
+			cpu, mem = podRequests(pod.Spec)
+			request  = resources{CPU: cpu, Memory: mem, Pods: 1}
+			assigned = false
+		)
+
+		for _, g := range groups {
+			if !g.accepts(pod.ObjectMeta.Namespace, pod.ObjectMeta.Labels, pod.Spec) {
+				continue
+			}
+
+			// Adding nodes won't help a pod which is bigger than a node.
+			if !request.fits(g.Node) {
+				continue
+			}
+
+			g.Pending = append(g.Pending, request)
+			assigned = true
+
+			break
+		}
+
+		if !assigned {
+			fmt.Fprintf(w, "Ignoring unschedulable pod %s/%s because: it can't be scheduled on any group\n", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package scaler
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+func TestParseGroup(t *testing.T) {
+	for _, tc := range []struct {
+		spec    string
+		name    string
+		options int
+		err     bool
+	}{
+		{spec: "nodes", name: "nodes"},
+		{spec: " nodes ; node-cpu = 3800 ", name: "nodes", options: 1},
+		{spec: "nodes-web;node-cpu=3800;node-mem=15000;min=1;max=10;namespaces=web,api;selector=tier=web", name: "nodes-web", options: 6},
+		{spec: ";node-cpu=3800", err: true},
+		{spec: "nodes;node-cpu", err: true},
+		{spec: "nodes;node-cpu=abc", err: true},
+		{spec: "nodes;node-cpu=0", err: true},
+		{spec: "nodes;min=-1", err: true},
+		{spec: "nodes;scale-down-cooldown=1 hour", err: true},
+		{spec: "nodes;selector=tier in (web", err: true},
+		{spec: "nodes;size=3", err: true},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			group, err := ParseGroup(tc.spec)
+
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if group.Name != tc.name {
+				t.Errorf("expected name %s, got %s", tc.name, group.Name)
+			}
+
+			if len(group.Options) != tc.options {
+				t.Errorf("expected %d options, got %v", tc.options, group.Options)
+			}
+		})
+	}
+}
+
+func TestResolveGroupConfig(t *testing.T) {
+	params := WatchParams{
+		NodeCPU:    1000,
+		NodeMemory: 2000,
+		NodePods:   30,
+		Cooldown: CooldownParams{
+			Down: 10 * time.Minute,
+		},
+	}
+
+	// Helper function to build an autoscaling group with option tags.
+	withTags := func(tags map[string]string) *autoscaling.Group {
+		asg := &autoscaling.Group{}
+
+		for key, value := range tags {
+			asg.Tags = append(asg.Tags, &autoscaling.TagDescription{
+				Key:   aws.String(key),
+				Value: aws.String(value),
+			})
+		}
+
+		return asg
+	}
+
+	for _, tc := range []struct {
+		name     string
+		options  map[string]string
+		tags     map[string]string
+		check    func(groupConfig) bool
+		expected string
+	}{
+		{
+			name:     "defaults",
+			check:    func(c groupConfig) bool { return c.NodeCPU == 1000 && c.NodeMemory == 2000 && c.NodePods == 30 },
+			expected: "the WatchParams defaults",
+		},
+		{
+			name:     "option overrides the default",
+			options:  map[string]string{"node-cpu": "3800"},
+			check:    func(c groupConfig) bool { return c.NodeCPU == 3800 },
+			expected: "node-cpu 3800",
+		},
+		{
+			name:     "tag overrides the option",
+			options:  map[string]string{"node-cpu": "3800"},
+			tags:     map[string]string{"k8s-aws-autoscaler/node-cpu": "7600"},
+			check:    func(c groupConfig) bool { return c.NodeCPU == 7600 },
+			expected: "node-cpu 7600",
+		},
+		{
+			name:     "tags without the prefix are ignored",
+			tags:     map[string]string{"node-cpu": "7600"},
+			check:    func(c groupConfig) bool { return c.NodeCPU == 1000 },
+			expected: "node-cpu 1000",
+		},
+		{
+			name:     "malformed tag keeps the default",
+			tags:     map[string]string{"k8s-aws-autoscaler/scale-down-cooldown": "1 hour"},
+			check:    func(c groupConfig) bool { return c.Cooldown.Down == 10*time.Minute },
+			expected: "scale-down-cooldown 10m",
+		},
+		{
+			name:     "malformed tag keeps the option",
+			options:  map[string]string{"node-cpu": "3800"},
+			tags:     map[string]string{"k8s-aws-autoscaler/node-cpu": "abc"},
+			check:    func(c groupConfig) bool { return c.NodeCPU == 3800 },
+			expected: "node-cpu 3800",
+		},
+		{
+			name:     "malformed selector tag keeps the option",
+			options:  map[string]string{"selector": "tier=web"},
+			tags:     map[string]string{"k8s-aws-autoscaler/selector": "tier in (web"},
+			check:    func(c groupConfig) bool { return c.Filter.Selector == "tier=web" },
+			expected: "selector tier=web",
+		},
+		{
+			name:     "unknown tag is ignored",
+			tags:     map[string]string{"k8s-aws-autoscaler/size": "3"},
+			check:    func(c groupConfig) bool { return c.MinSize == nil && c.MaxSize == nil },
+			expected: "no min or max",
+		},
+		{
+			name:     "min and max",
+			options:  map[string]string{"min": "2"},
+			tags:     map[string]string{"k8s-aws-autoscaler/max": "5"},
+			check:    func(c groupConfig) bool { return aws.Int64Value(c.MinSize) == 2 && aws.Int64Value(c.MaxSize) == 5 },
+			expected: "min 2 and max 5",
+		},
+		{
+			name:     "min greater than max is ignored",
+			options:  map[string]string{"min": "5"},
+			tags:     map[string]string{"k8s-aws-autoscaler/max": "2"},
+			check:    func(c groupConfig) bool { return c.MinSize == nil && c.MaxSize == nil },
+			expected: "no min or max",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			declared := GroupParams{
+				Name:    "nodes",
+				Options: tc.options,
+			}
+
+			config := resolveGroupConfig(ioutil.Discard, params, declared, withTags(tc.tags))
+
+			if !tc.check(config) {
+				t.Errorf("expected %s, got %+v", tc.expected, config)
+			}
+		})
+	}
+}
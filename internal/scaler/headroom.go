@@ -0,0 +1,45 @@
+package scaler
+
+import (
+	"math"
+)
+
+// HeadroomParams declares spare capacity which is added on top of the demand.
+type HeadroomParams struct {
+	// Nodes which are always kept spare.
+	Nodes int
+	// Percent of the nodes required by demand which are kept spare.
+	Percent float64
+	// CPU which is kept spare, rounded up to whole nodes.
+	CPU int
+	// Memory which is kept spare, rounded up to whole nodes.
+	Memory int
+}
+
+// plan is the amount of nodes the group requires.
+type plan struct {
+	// Demand is the amount of nodes required to run the workloads.
+	Demand int64
+	// Headroom is the amount of spare nodes.
+	Headroom int64
+}
+
+// Helper function to return the total amount of nodes in the plan.
+func (p plan) Total() int64 {
+	return p.Demand + p.Headroom
+}
+
+// Helper function to determine how many spare nodes to add on top of the demand.
+func getHeadroom(demand int64, node resources, params HeadroomParams) int64 {
+	headroom := int64(params.Nodes)
+
+	if params.Percent > 0 {
+		headroom = headroom + int64(math.Ceil(float64(demand)*params.Percent/100))
+	}
+
+	if params.CPU > 0 || params.Memory > 0 {
+		headroom = headroom + getDesiredAggregate(params.CPU, params.Memory, node.CPU, node.Memory)
+	}
+
+	return headroom
+}
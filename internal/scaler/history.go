@@ -0,0 +1,195 @@
+package scaler
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sample is the demand of a group which was recorded during a cycle.
+type sample struct {
+	Group  string    `json:"group"`
+	Time   time.Time `json:"time"`
+	CPU    int       `json:"cpu"`
+	Memory int       `json:"memory"`
+	Pods   int       `json:"pods"`
+}
+
+// Helper function to convert the sample into resources.
+func (s sample) resources() resources {
+	return resources{
+		CPU:    s.CPU,
+		Memory: s.Memory,
+		Pods:   s.Pods,
+	}
+}
+
+// history is a local store of the demand recorded for each group, kept as a file with one JSON sample per line.
+type history struct {
+	path      string
+	retention time.Duration
+	// Samples of each group, ordered by time.
+	samples map[string][]sample
+}
+
+// Helper function to open the history, loading the samples which have already been recorded.
+func openHistory(path string, retention time.Duration) (*history, error) {
+	h := &history{
+		path:      path,
+		retention: retention,
+		samples:   make(map[string][]sample),
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		var s sample
+
+		// A line which was only partially written eg. during a crash is skipped.
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			continue
+		}
+
+		h.samples[s.Group] = append(h.samples[s.Group], s)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read history")
+	}
+
+	for group := range h.samples {
+		samples := h.samples[group]
+
+		sort.SliceStable(samples, func(i, j int) bool {
+			return samples[i].Time.Before(samples[j].Time)
+		})
+	}
+
+	return h, nil
+}
+
+// Helper function to record a sample, appending it to the file.
+// Samples older than the retention are dropped, rewriting the file at most once an hour.
+func (h *history) record(s sample) error {
+	h.samples[s.Group] = append(h.samples[s.Group], s)
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+
+	if h.expired(s.Time.Add(-time.Hour)) {
+		return h.compact(s.Time)
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return json.NewEncoder(file).Encode(s)
+}
+
+// Helper function to determine if any samples have been held past the retention at a time.
+func (h *history) expired(now time.Time) bool {
+	for _, samples := range h.samples {
+		if len(samples) > 0 && samples[0].Time.Before(now.Add(-h.retention)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper function to drop the samples which are older than the retention and rewrite the file.
+// The file is replaced atomically, so a crash doesn't lose the history.
+func (h *history) compact(now time.Time) error {
+	tmp := h.path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(file)
+
+	for group, samples := range h.samples {
+		var kept []sample
+
+		for _, s := range samples {
+			if s.Time.Before(now.Add(-h.retention)) {
+				continue
+			}
+
+			kept = append(kept, s)
+
+			if err := encoder.Encode(s); err != nil {
+				file.Close()
+				return err
+			}
+		}
+
+		h.samples[group] = kept
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, h.path)
+}
+
+// Helper function to find the highest demand recorded for a group between two times.
+// Returns false when nothing was recorded.
+func (h *history) peak(group string, from, to time.Time) (resources, bool) {
+	var (
+		samples = h.samples[group]
+		peak    resources
+		found   bool
+	)
+
+	start := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].Time.Before(from)
+	})
+
+	for _, s := range samples[start:] {
+		if !s.Time.Before(to) {
+			break
+		}
+
+		peak = peak.max(s.resources())
+		found = true
+	}
+
+	return peak, found
+}
+
+// Helper function to list the groups which have recorded history, ordered by name.
+func (h *history) groups() []string {
+	var groups []string
+
+	for group := range h.samples {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	return groups
+}
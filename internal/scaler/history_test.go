@@ -0,0 +1,182 @@
+package scaler
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Helper function to count the lines in a file.
+func countLines(t *testing.T, path string) int {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %s", path, err)
+	}
+
+	defer file.Close()
+
+	var (
+		scanner = bufio.NewScanner(file)
+		lines   int
+	)
+
+	for scanner.Scan() {
+		lines++
+	}
+
+	return lines
+}
+
+func TestHistoryRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	var (
+		path = filepath.Join(dir, "nested", "history.jsonl")
+		now  = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	)
+
+	h, err := openHistory(path, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open history: %s", err)
+	}
+
+	for _, s := range []sample{
+		{Group: "web", Time: now, CPU: 100},
+		{Group: "api", Time: now, CPU: 200},
+		{Group: "web", Time: now.Add(time.Hour), CPU: 300},
+		{Group: "web", Time: now.Add(2 * time.Hour), CPU: 400},
+		// Samples are only compacted once they are an hour past the retention, so this one is appended.
+		{Group: "web", Time: now.Add(3 * time.Hour), CPU: 500},
+	} {
+		if err := h.record(s); err != nil {
+			t.Fatalf("failed to record sample: %s", err)
+		}
+	}
+
+	if lines := countLines(t, path); lines != 5 {
+		t.Errorf("expected 5 samples to be appended, got %d", lines)
+	}
+
+	// A line which was only partially written is skipped when the history is opened.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open history: %s", err)
+	}
+
+	if _, err := file.WriteString(`{"group":"web","ti`); err != nil {
+		t.Fatalf("failed to write partial sample: %s", err)
+	}
+
+	file.Close()
+
+	h, err = openHistory(path, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open history: %s", err)
+	}
+
+	if len(h.samples["web"]) != 4 || len(h.samples["api"]) != 1 {
+		t.Fatalf("expected 4 web and 1 api samples, got %v", h.samples)
+	}
+
+	// Samples which are older than the retention are dropped and the file is rewritten.
+	if err := h.record(sample{Group: "web", Time: now.Add(3*time.Hour + 30*time.Minute), CPU: 600}); err != nil {
+		t.Fatalf("failed to record sample: %s", err)
+	}
+
+	if lines := countLines(t, path); lines != 3 {
+		t.Errorf("expected 3 samples after compacting, got %d", lines)
+	}
+
+	h, err = openHistory(path, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to open history: %s", err)
+	}
+
+	if len(h.samples["api"]) != 0 {
+		t.Errorf("expected the api samples to be dropped, got %v", h.samples["api"])
+	}
+
+	web := h.samples["web"]
+
+	if len(web) != 3 || web[0].CPU != 400 || web[2].CPU != 600 {
+		t.Errorf("expected the last 3 web samples in order, got %v", web)
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be renamed")
+	}
+}
+
+func TestHistoryPeak(t *testing.T) {
+	var (
+		now = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		h   = &history{
+			samples: map[string][]sample{
+				"web": {
+					{Time: now, CPU: 100, Memory: 900, Pods: 1},
+					{Time: now.Add(10 * time.Minute), CPU: 500, Memory: 100, Pods: 3},
+					{Time: now.Add(20 * time.Minute), CPU: 300, Memory: 200, Pods: 2},
+					{Time: now.Add(time.Hour), CPU: 1000, Memory: 1000, Pods: 10},
+				},
+			},
+		}
+	)
+
+	for _, tc := range []struct {
+		name     string
+		group    string
+		from, to time.Time
+		peak     resources
+		found    bool
+	}{
+		{
+			// Each resource peaks separately.
+			name:  "highest of each resource",
+			group: "web",
+			from:  now,
+			to:    now.Add(30 * time.Minute),
+			peak:  resources{CPU: 500, Memory: 900, Pods: 3},
+			found: true,
+		},
+		{
+			name:  "from is inclusive",
+			group: "web",
+			from:  now.Add(20 * time.Minute),
+			to:    now.Add(30 * time.Minute),
+			peak:  resources{CPU: 300, Memory: 200, Pods: 2},
+			found: true,
+		},
+		{
+			name:  "to is exclusive",
+			group: "web",
+			from:  now.Add(30 * time.Minute),
+			to:    now.Add(time.Hour),
+		},
+		{
+			name:  "another group",
+			group: "api",
+			from:  now,
+			to:    now.Add(2 * time.Hour),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			peak, found := h.peak(tc.group, tc.from, tc.to)
+
+			if found != tc.found {
+				t.Errorf("expected found %t, got %t", tc.found, found)
+			}
+
+			if peak != tc.peak {
+				t.Errorf("expected %+v, got %+v", tc.peak, peak)
+			}
+		})
+	}
+}
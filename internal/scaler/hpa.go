@@ -0,0 +1,111 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// HPAModeCurrent counts the replicas currently declared on the workload.
+	HPAModeCurrent = "current"
+	// HPAModeMin counts the minimum replicas of the HorizontalPodAutoscaler.
+	HPAModeMin = "min"
+	// HPAModeMax counts the maximum replicas of the HorizontalPodAutoscaler.
+	HPAModeMax = "max"
+	// HPAModePercentile counts a percentile between the minimum and maximum replicas of the HorizontalPodAutoscaler.
+	HPAModePercentile = "percentile"
+)
+
+// HPAModes which can be used for counting replicas.
+var HPAModes = []string{
+	HPAModeCurrent,
+	HPAModeMin,
+	HPAModeMax,
+	HPAModePercentile,
+}
+
+// hpaBounds are the replica bounds of a HorizontalPodAutoscaler.
+type hpaBounds struct {
+	Min int
+	Max int
+}
+
+// Helper function to lookup the replica bounds of each HorizontalPodAutoscaler, keyed by the workload they target.
+func getHPABounds(k8s *kubernetes.Clientset) (map[string]hpaBounds, error) {
+	hpas, err := k8s.AutoscalingV1().HorizontalPodAutoscalers(corev1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := make(map[string]hpaBounds)
+
+	for _, hpa := range hpas.Items {
+		key := workloadKey(hpa.Spec.ScaleTargetRef.Kind, hpa.ObjectMeta.Namespace, hpa.Spec.ScaleTargetRef.Name)
+
+		bounds[key] = hpaBounds{
+			Min: replicas(hpa.Spec.MinReplicas),
+			Max: int(hpa.Spec.MaxReplicas),
+		}
+	}
+
+	return bounds, nil
+}
+
+// Helper function to build a key which identifies a workload.
+func workloadKey(kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// Helper function to set the replicas of workloads managed by a HorizontalPodAutoscaler.
+// The mode and percentile can be overridden on each workload with annotations.
+func applyHPABounds(w io.Writer, workloads []workload, bounds map[string]hpaBounds, mode string, percentile int) {
+	for i, workload := range workloads {
+		b, ok := bounds[workloadKey(workload.Kind, workload.Namespace, workload.Name)]
+		if !ok {
+			continue
+		}
+
+		var (
+			workloadMode       = mode
+			workloadPercentile = percentile
+		)
+
+		if value, ok := workload.Annotations[AnnotationHPAMode]; ok {
+			if contains(HPAModes, value) {
+				workloadMode = value
+			} else {
+				fmt.Fprintf(w, "Ignoring invalid %s annotation on %s %s/%s: %s\n", AnnotationHPAMode, workload.Kind, workload.Namespace, workload.Name, value)
+			}
+		}
+
+		if value, ok := workload.Annotations[AnnotationHPAPercentile]; ok {
+			if p, err := strconv.Atoi(value); err == nil && p >= 0 && p <= 100 {
+				workloadPercentile = p
+			} else {
+				fmt.Fprintf(w, "Ignoring invalid %s annotation on %s %s/%s: %s\n", AnnotationHPAPercentile, workload.Kind, workload.Namespace, workload.Name, value)
+			}
+		}
+
+		workloads[i].Replicas = hpaReplicas(workload.Replicas, b, workloadMode, workloadPercentile)
+	}
+}
+
+// Helper function to determine how many replicas to count for a workload managed by a HorizontalPodAutoscaler.
+func hpaReplicas(current int, bounds hpaBounds, mode string, percentile int) int {
+	switch mode {
+	case HPAModeMin:
+		return bounds.Min
+	case HPAModeMax:
+		return bounds.Max
+	case HPAModePercentile:
+		return bounds.Min + int(math.Ceil(float64(bounds.Max-bounds.Min)*float64(percentile)/100))
+	}
+
+	return current
+}
@@ -0,0 +1,68 @@
+package scaler
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestHPAReplicas(t *testing.T) {
+	bounds := hpaBounds{Min: 2, Max: 10}
+
+	for _, tc := range []struct {
+		mode       string
+		percentile int
+		expected   int
+	}{
+		{HPAModeCurrent, 0, 5},
+		{HPAModeMin, 0, 2},
+		{HPAModeMax, 0, 10},
+		{HPAModePercentile, 0, 2},
+		{HPAModePercentile, 50, 6},
+		// Percentiles which fall between replicas are rounded up.
+		{HPAModePercentile, 30, 5},
+		{HPAModePercentile, 100, 10},
+		{"unknown", 0, 5},
+	} {
+		if replicas := hpaReplicas(5, bounds, tc.mode, tc.percentile); replicas != tc.expected {
+			t.Errorf("mode %s with percentile %d: expected %d replicas, got %d", tc.mode, tc.percentile, tc.expected, replicas)
+		}
+	}
+}
+
+func TestApplyHPABounds(t *testing.T) {
+	bounds := map[string]hpaBounds{
+		workloadKey("Deployment", "default", "web"): {Min: 2, Max: 10},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		kind        string
+		annotations map[string]string
+		expected    int
+	}{
+		{"not managed by an HPA", "StatefulSet", nil, 5},
+		{"uses the default mode", "Deployment", nil, 10},
+		{"mode annotation", "Deployment", map[string]string{AnnotationHPAMode: HPAModeMin}, 2},
+		{"percentile annotation", "Deployment", map[string]string{AnnotationHPAMode: HPAModePercentile, AnnotationHPAPercentile: "50"}, 6},
+		{"invalid mode annotation", "Deployment", map[string]string{AnnotationHPAMode: "median"}, 10},
+		{"invalid percentile annotation", "Deployment", map[string]string{AnnotationHPAMode: HPAModePercentile, AnnotationHPAPercentile: "150"}, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			workloads := []workload{
+				{
+					Kind:        tc.kind,
+					Namespace:   "default",
+					Name:        "web",
+					Annotations: tc.annotations,
+					Replicas:    5,
+				},
+			}
+
+			applyHPABounds(ioutil.Discard, workloads, bounds, HPAModeMax, 25)
+
+			if workloads[0].Replicas != tc.expected {
+				t.Errorf("expected %d replicas, got %d", tc.expected, workloads[0].Replicas)
+			}
+		})
+	}
+}
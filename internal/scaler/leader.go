@@ -0,0 +1,341 @@
+package scaler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AnnotationLeader is the annotation on the ConfigMap which records the leader, compatible with client-go's ConfigMap lock.
+const AnnotationLeader = "control-plane.alpha.kubernetes.io/leader"
+
+// LeaderElectionParams declares how replicas elect a leader, so only one of them makes changes at a time.
+type LeaderElectionParams struct {
+	// Enabled runs leader election, otherwise this replica is always the leader.
+	Enabled bool
+	// Namespace of the ConfigMap which is used as the lock.
+	Namespace string
+	// Name of the ConfigMap which is used as the lock.
+	Name string
+	// Identity of this replica, defaults to the hostname.
+	Identity string
+	// LeaseDuration standby replicas wait before taking over from a leader which stopped renewing.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader retries renewing before it stops acting as the leader.
+	RenewDeadline time.Duration
+	// RetryPeriod between attempts to acquire or renew the lease.
+	RetryPeriod time.Duration
+}
+
+// leaderRecord is stored in the lock's annotation.
+type leaderRecord struct {
+	HolderIdentity       string      `json:"holderIdentity"`
+	LeaseDurationSeconds int         `json:"leaseDurationSeconds"`
+	AcquireTime          metav1.Time `json:"acquireTime"`
+	RenewTime            metav1.Time `json:"renewTime"`
+	LeaderTransitions    int         `json:"leaderTransitions"`
+}
+
+// elector acquires and renews the lease in the background.
+type elector struct {
+	params LeaderElectionParams
+	k8s    *kubernetes.Clientset
+
+	mu     sync.Mutex
+	leader bool
+	// Term is cancelled when this replica stops being the leader.
+	term    context.Context
+	endTerm context.CancelFunc
+	// The record which was last observed and when, so expiry doesn't depend on the clocks of other replicas.
+	observed     leaderRecord
+	observedTime time.Time
+}
+
+// Helper function to validate the leader election durations and default the identity.
+func (p *LeaderElectionParams) validate() error {
+	if p.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return errors.Wrap(err, "failed to lookup hostname for the leader election identity")
+		}
+
+		p.Identity = hostname
+	}
+
+	if p.RetryPeriod <= 0 {
+		return errors.Errorf("leader election retry period must be greater than zero: %s", p.RetryPeriod)
+	}
+
+	if p.RenewDeadline <= p.RetryPeriod {
+		return errors.Errorf("leader election renew deadline (%s) must be greater than the retry period (%s)", p.RenewDeadline, p.RetryPeriod)
+	}
+
+	if p.LeaseDuration <= p.RenewDeadline {
+		return errors.Errorf("leader election lease duration (%s) must be greater than the renew deadline (%s)", p.LeaseDuration, p.RenewDeadline)
+	}
+
+	return nil
+}
+
+// Helper function to determine if this replica is currently the leader.
+func (e *elector) isLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.leader
+}
+
+// Helper function to update whether this replica is the leader, reporting transitions.
+func (e *elector) setLeader(w io.Writer, leader bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if leader && !e.leader {
+		fmt.Fprintf(w, "Became the leader as: %s\n", e.params.Identity)
+		e.term, e.endTerm = context.WithCancel(context.Background())
+	}
+
+	if !leader && e.leader {
+		fmt.Fprintf(w, "No longer the leader as: %s\n", e.params.Identity)
+		e.endTerm()
+	}
+
+	e.leader = leader
+}
+
+// Helper function to derive a context from the parent which is also cancelled when this replica stops being the leader,
+// so a cycle which is in flight stops making changes once another replica could take over.
+func (e *elector) leading(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+
+	e.mu.Lock()
+	leader, term := e.leader, e.term
+	e.mu.Unlock()
+
+	if !leader {
+		cancel()
+		return ctx, cancel
+	}
+
+	go func() {
+		select {
+		case <-term.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// Helper function to acquire and renew the lease until the context is cancelled, then release it.
+// The leader stops acting as the leader when it could not renew the lease within the renew deadline.
+func (e *elector) run(ctx context.Context, w io.Writer) {
+	var renewed time.Time
+
+	for {
+		if ctx.Err() != nil {
+			e.release(w)
+			return
+		}
+
+		acquired, err := e.tryAcquireOrRenew(time.Now())
+
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "WARNING: Failed to acquire or renew leader election lease: %s\n", err)
+
+			if e.isLeader() && time.Since(renewed) > e.params.RenewDeadline {
+				e.setLeader(w, false)
+			}
+		case acquired:
+			renewed = time.Now()
+			e.setLeader(w, true)
+		default:
+			e.setLeader(w, false)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(e.params.RetryPeriod):
+		}
+	}
+}
+
+// Helper function to acquire the lease when it is free or expired, or renew it when this replica holds it.
+// Returns false when another replica holds the lease.
+func (e *elector) tryAcquireOrRenew(now time.Time) (bool, error) {
+	record := leaderRecord{
+		HolderIdentity:       e.params.Identity,
+		LeaseDurationSeconds: int(e.params.LeaseDuration / time.Second),
+		AcquireTime:          metav1.NewTime(now),
+		RenewTime:            metav1.NewTime(now),
+	}
+
+	cm, err := e.k8s.CoreV1().ConfigMaps(e.params.Namespace).Get(e.params.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = e.k8s.CoreV1().ConfigMaps(e.params.Namespace).Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: e.params.Namespace,
+				Name:      e.params.Name,
+				Annotations: map[string]string{
+					AnnotationLeader: string(data),
+				},
+			},
+		})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		e.observe(record, now)
+
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var existing leaderRecord
+
+	if data, ok := cm.ObjectMeta.Annotations[AnnotationLeader]; ok {
+		if err := json.Unmarshal([]byte(data), &existing); err != nil {
+			return false, errors.Wrap(err, "failed to decode leader record")
+		}
+	}
+
+	observedTime := e.observe(existing, now)
+
+	held := existing.HolderIdentity != "" && existing.HolderIdentity != e.params.Identity
+	expired := observedTime.Add(time.Duration(existing.LeaseDurationSeconds) * time.Second).Before(now)
+
+	if held && !expired {
+		return false, nil
+	}
+
+	if existing.HolderIdentity == e.params.Identity {
+		record.AcquireTime = existing.AcquireTime
+		record.LeaderTransitions = existing.LeaderTransitions
+	} else {
+		record.LeaderTransitions = existing.LeaderTransitions + 1
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+
+	if cm.ObjectMeta.Annotations == nil {
+		cm.ObjectMeta.Annotations = make(map[string]string)
+	}
+
+	cm.ObjectMeta.Annotations[AnnotationLeader] = string(data)
+
+	// The update conflicts when another replica updated the lock since we fetched it.
+	_, err = e.k8s.CoreV1().ConfigMaps(e.params.Namespace).Update(cm)
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	e.observe(record, now)
+
+	return true, nil
+}
+
+// Helper function to track when a record was first observed, returning that time.
+func (e *elector) observe(record leaderRecord, now time.Time) time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if record != e.observed {
+		e.observed = record
+		e.observedTime = now
+	}
+
+	return e.observedTime
+}
+
+// Helper function to give up the lease when this replica holds it, so standby replicas can take over
+// without waiting for the lease to expire.
+func (e *elector) release(w io.Writer) {
+	if !e.isLeader() {
+		return
+	}
+
+	e.setLeader(w, false)
+
+	// The lease is released after the elector's context was cancelled, so it is given the renew deadline instead.
+	ctx, cancel := context.WithTimeout(context.Background(), e.params.RenewDeadline)
+	defer cancel()
+
+	k8s, err := newClient(ctx)
+	if err != nil {
+		fmt.Fprintf(w, "WARNING: Failed to release leader election lease: %s\n", err)
+		return
+	}
+
+	cm, err := k8s.CoreV1().ConfigMaps(e.params.Namespace).Get(e.params.Name, metav1.GetOptions{})
+	if err != nil {
+		fmt.Fprintf(w, "WARNING: Failed to release leader election lease: %s\n", err)
+		return
+	}
+
+	var existing leaderRecord
+
+	if data, ok := cm.ObjectMeta.Annotations[AnnotationLeader]; ok {
+		if err := json.Unmarshal([]byte(data), &existing); err != nil {
+			fmt.Fprintf(w, "WARNING: Failed to release leader election lease: failed to decode leader record: %s\n", err)
+			return
+		}
+	}
+
+	// Another replica already took over.
+	if existing.HolderIdentity != e.params.Identity {
+		return
+	}
+
+	now := metav1.Now()
+
+	data, err := json.Marshal(leaderRecord{
+		LeaseDurationSeconds: 1,
+		AcquireTime:          now,
+		RenewTime:            now,
+		LeaderTransitions:    existing.LeaderTransitions,
+	})
+	if err != nil {
+		fmt.Fprintf(w, "WARNING: Failed to release leader election lease: %s\n", err)
+		return
+	}
+
+	cm.ObjectMeta.Annotations[AnnotationLeader] = string(data)
+
+	if _, err := k8s.CoreV1().ConfigMaps(e.params.Namespace).Update(cm); err != nil {
+		fmt.Fprintf(w, "WARNING: Failed to release leader election lease: %s\n", err)
+		return
+	}
+
+	fmt.Fprintf(w, "Released the leader election lease as: %s\n", e.params.Identity)
+}
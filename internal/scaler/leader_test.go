@@ -0,0 +1,269 @@
+package scaler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// configMapServer is an API server which only stores ConfigMaps, enough to run the elector against.
+type configMapServer struct {
+	mu         sync.Mutex
+	configMaps map[string]corev1.ConfigMap
+	version    int
+	// Conflict makes the next update fail, as if another replica updated the lock first.
+	conflict bool
+}
+
+// ServeHTTP handles getting, creating and updating ConfigMaps.
+func (s *configMapServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// eg. /api/v1/namespaces/kube-system/configmaps/leader
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 5 || parts[4] != "configmaps" {
+		writeStatus(w, apierrors.NewNotFound(schema.GroupResource{}, r.URL.Path))
+		return
+	}
+
+	var (
+		resource = schema.GroupResource{Resource: "configmaps"}
+		name     string
+	)
+
+	if len(parts) == 6 {
+		name = parts[5]
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cm, ok := s.configMaps[name]
+		if !ok {
+			writeStatus(w, apierrors.NewNotFound(resource, name))
+			return
+		}
+
+		writeObject(w, http.StatusOK, cm)
+	case http.MethodPost:
+		var cm corev1.ConfigMap
+
+		if err := json.NewDecoder(r.Body).Decode(&cm); err != nil {
+			writeStatus(w, apierrors.NewBadRequest(err.Error()))
+			return
+		}
+
+		if _, ok := s.configMaps[cm.ObjectMeta.Name]; ok {
+			writeStatus(w, apierrors.NewAlreadyExists(resource, cm.ObjectMeta.Name))
+			return
+		}
+
+		writeObject(w, http.StatusCreated, s.store(cm))
+	case http.MethodPut:
+		var cm corev1.ConfigMap
+
+		if err := json.NewDecoder(r.Body).Decode(&cm); err != nil {
+			writeStatus(w, apierrors.NewBadRequest(err.Error()))
+			return
+		}
+
+		if s.conflict || cm.ObjectMeta.ResourceVersion != s.configMaps[name].ObjectMeta.ResourceVersion {
+			s.conflict = false
+			writeStatus(w, apierrors.NewConflict(resource, name, nil))
+			return
+		}
+
+		writeObject(w, http.StatusOK, s.store(cm))
+	default:
+		writeStatus(w, apierrors.NewMethodNotSupported(resource, r.Method))
+	}
+}
+
+// Helper function to store a ConfigMap with a new resource version.
+func (s *configMapServer) store(cm corev1.ConfigMap) corev1.ConfigMap {
+	s.version++
+
+	cm.ObjectMeta.ResourceVersion = strconv.Itoa(s.version)
+	s.configMaps[cm.ObjectMeta.Name] = cm
+
+	return cm
+}
+
+// Helper function to read the leader record which is stored in the lock.
+func (s *configMapServer) record(t *testing.T, name string) leaderRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var record leaderRecord
+
+	if err := json.Unmarshal([]byte(s.configMaps[name].ObjectMeta.Annotations[AnnotationLeader]), &record); err != nil {
+		t.Fatalf("failed to decode leader record: %s", err)
+	}
+
+	return record
+}
+
+// Helper function to respond with an object.
+func writeObject(w http.ResponseWriter, code int, cm corev1.ConfigMap) {
+	cm.TypeMeta = metav1.TypeMeta{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(cm)
+}
+
+// Helper function to respond with an API error.
+func writeStatus(w http.ResponseWriter, err *apierrors.StatusError) {
+	status := err.ErrStatus
+	status.TypeMeta = metav1.TypeMeta{
+		APIVersion: "v1",
+		Kind:       "Status",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(int(status.Code))
+	json.NewEncoder(w).Encode(status)
+}
+
+// Helper function to create an elector for an identity, along with the API server it runs against.
+func testElector(t *testing.T, server *configMapServer, identity string) *elector {
+	api := httptest.NewServer(server)
+	t.Cleanup(api.Close)
+
+	k8s, err := kubernetes.NewForConfig(&rest.Config{Host: api.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+
+	return &elector{
+		params: LeaderElectionParams{
+			Namespace:     "kube-system",
+			Name:          "leader",
+			Identity:      identity,
+			LeaseDuration: 15 * time.Second,
+			RenewDeadline: 10 * time.Second,
+			RetryPeriod:   2 * time.Second,
+		},
+		k8s: k8s,
+	}
+}
+
+func TestElectorTryAcquireOrRenew(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		server = &configMapServer{configMaps: make(map[string]corev1.ConfigMap)}
+		a      = testElector(t, server, "a")
+		b      = testElector(t, server, "b")
+	)
+
+	// Helper function to attempt to acquire or renew the lease, failing the test when the outcome isn't expected.
+	attempt := func(e *elector, at time.Time, expected bool) {
+		t.Helper()
+
+		acquired, err := e.tryAcquireOrRenew(at)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if acquired != expected {
+			t.Fatalf("expected %s to acquire %t, got %t", e.params.Identity, expected, acquired)
+		}
+	}
+
+	// The lock is created by the first replica.
+	attempt(a, now, true)
+
+	if record := server.record(t, "leader"); record.HolderIdentity != "a" || record.LeaseDurationSeconds != 15 {
+		t.Errorf("expected a to hold the lease for 15s, got %+v", record)
+	}
+
+	// The leader renews the lease, keeping when it was acquired.
+	attempt(a, now.Add(2*time.Second), true)
+
+	if record := server.record(t, "leader"); !record.AcquireTime.Time.Equal(now) || !record.RenewTime.Time.Equal(now.Add(2*time.Second)) {
+		t.Errorf("expected the lease to be renewed, got %+v", record)
+	}
+
+	// Another replica can't acquire the lease while it is held.
+	attempt(b, now.Add(4*time.Second), false)
+
+	// The lease only expires once it hasn't changed for the lease duration since it was observed, regardless of the
+	// times recorded by the leader.
+	attempt(b, now.Add(10*time.Second), false)
+	attempt(b, now.Add(20*time.Second), true)
+
+	if record := server.record(t, "leader"); record.HolderIdentity != "b" || record.LeaderTransitions != 1 {
+		t.Errorf("expected b to hold the lease after 1 transition, got %+v", record)
+	}
+
+	// The previous leader no longer holds the lease.
+	attempt(a, now.Add(22*time.Second), false)
+
+	// An update which conflicts with another replica's doesn't acquire the lease.
+	server.mu.Lock()
+	server.conflict = true
+	server.mu.Unlock()
+
+	attempt(b, now.Add(22*time.Second), false)
+	attempt(b, now.Add(24*time.Second), true)
+}
+
+func TestElectorObservedExpiry(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		server = &configMapServer{configMaps: make(map[string]corev1.ConfigMap)}
+		a      = testElector(t, server, "a")
+	)
+
+	// The lock was renewed by another replica whose clock is an hour behind.
+	record, err := json.Marshal(leaderRecord{
+		HolderIdentity:       "b",
+		LeaseDurationSeconds: 15,
+		AcquireTime:          metav1.NewTime(now.Add(-time.Hour)),
+		RenewTime:            metav1.NewTime(now.Add(-time.Hour)),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode leader record: %s", err)
+	}
+
+	server.store(corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "kube-system",
+			Name:        "leader",
+			Annotations: map[string]string{AnnotationLeader: string(record)},
+		},
+	})
+
+	for _, tc := range []struct {
+		at       time.Time
+		acquired bool
+	}{
+		{now, false},
+		{now.Add(15 * time.Second), false},
+		{now.Add(16 * time.Second), true},
+	} {
+		acquired, err := a.tryAcquireOrRenew(tc.at)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if acquired != tc.acquired {
+			t.Fatalf("expected acquired %t at %s, got %t", tc.acquired, tc.at, acquired)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package scaler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// LifecycleTransitionTerminating is the transition of lifecycle hooks which pause an instance before it is terminated.
+	LifecycleTransitionTerminating = "autoscaling:EC2_INSTANCE_TERMINATING"
+	// LifecycleActionContinue allows the instance to be terminated.
+	LifecycleActionContinue = "CONTINUE"
+)
+
+// terminating is an instance which is waiting on a lifecycle hook before it is terminated.
+type terminating struct {
+	Group    string
+	Instance string
+	// Hooks which are waiting for the instance.
+	Hooks []string
+}
+
+// Helper function to list the instances of the managed groups which are waiting on a termination lifecycle hook.
+// Hooks are discovered by polling, so no queue service needs to be configured for them.
+func getTerminatingInstances(ctx context.Context, svc *autoscaling.AutoScaling, groups []*group) ([]terminating, error) {
+	managed := make(map[string]bool)
+
+	for _, g := range groups {
+		managed[g.Name] = true
+	}
+
+	var instances []terminating
+
+	err := svc.DescribeAutoScalingInstancesPagesWithContext(ctx, &autoscaling.DescribeAutoScalingInstancesInput{
+		MaxRecords: aws.Int64(50),
+	}, func(page *autoscaling.DescribeAutoScalingInstancesOutput, last bool) bool {
+		for _, instance := range page.AutoScalingInstances {
+			if !managed[aws.StringValue(instance.AutoScalingGroupName)] {
+				continue
+			}
+
+			if aws.StringValue(instance.LifecycleState) != autoscaling.LifecycleStateTerminatingWait {
+				continue
+			}
+
+			instances = append(instances, terminating{
+				Group:    aws.StringValue(instance.AutoScalingGroupName),
+				Instance: aws.StringValue(instance.InstanceId),
+			})
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to describe instances")
+	}
+
+	hooks := make(map[string][]string)
+
+	for i, instance := range instances {
+		if _, ok := hooks[instance.Group]; !ok {
+			hooks[instance.Group], err = getTerminatingHooks(ctx, svc, instance.Group)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to describe lifecycle hooks for group %s", instance.Group)
+			}
+		}
+
+		instances[i].Hooks = hooks[instance.Group]
+	}
+
+	return instances, nil
+}
+
+// Helper function to list the names of a group's lifecycle hooks which pause instances before they are terminated.
+func getTerminatingHooks(ctx context.Context, svc *autoscaling.AutoScaling, name string) ([]string, error) {
+	resp, err := svc.DescribeLifecycleHooksWithContext(ctx, &autoscaling.DescribeLifecycleHooksInput{
+		AutoScalingGroupName: aws.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var hooks []string
+
+	for _, hook := range resp.LifecycleHooks {
+		if aws.StringValue(hook.LifecycleTransition) == LifecycleTransitionTerminating {
+			hooks = append(hooks, aws.StringValue(hook.LifecycleHookName))
+		}
+	}
+
+	return hooks, nil
+}
+
+// hookDrains tracks the drains of terminating instances which run in the background, so the cycle isn't held up while
+// the nodes are drained.
+type hookDrains struct {
+	mu       sync.Mutex
+	inflight map[string]bool
+	wg       sync.WaitGroup
+}
+
+// Helper function to create an empty set of background drains.
+func newHookDrains() *hookDrains {
+	return &hookDrains{
+		inflight: make(map[string]bool),
+	}
+}
+
+// Helper function to determine if an instance is being drained in the background.
+func (h *hookDrains) running(instance string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.inflight[instance]
+}
+
+// Helper function to run a drain of an instance in the background.
+func (h *hookDrains) start(instance string, fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.inflight[instance] = true
+	h.wg.Add(1)
+
+	go func() {
+		defer h.wg.Done()
+
+		fn()
+
+		h.mu.Lock()
+		delete(h.inflight, instance)
+		h.mu.Unlock()
+	}()
+}
+
+// Helper function to wait for the drains which are running in the background.
+func (h *hookDrains) wait() {
+	h.wg.Wait()
+}
+
+// Helper function to drain the nodes of instances which are waiting on a termination lifecycle hook,
+// so their pods are evicted gracefully instead of being killed when AWS terminates the instance.
+// Each node is drained in the background with its own drainer, since a drain can take up to the drain timeout.
+func handleLifecycleHooks(ctx context.Context, w io.Writer, svc *autoscaling.AutoScaling, hooks *hookDrains, newDrainer func() (*drainer, error), groups []*group, nodes []corev1.Node, params WatchParams) error {
+	instances, err := getTerminatingInstances(ctx, svc, groups)
+	if err != nil {
+		return err
+	}
+
+	for _, instance := range instances {
+		if hooks.running(instance.Instance) {
+			fmt.Fprintf(w, "Instance %s of group %s is still being drained\n", instance.Instance, instance.Group)
+			continue
+		}
+
+		fmt.Fprintf(w, "Instance %s of group %s is waiting to be terminated\n", instance.Instance, instance.Group)
+
+		// Don't make any changes. Perfect for debugging.
+		if params.DryRun {
+			continue
+		}
+
+		// No more nodes are drained once the cycle is cancelled, the instances are handled again in a later cycle.
+		if ctx.Err() != nil {
+			return errors.Errorf("stopped handling lifecycle hooks because: the cycle was cancelled")
+		}
+
+		d, err := newDrainer()
+		if err != nil {
+			return err
+		}
+
+		instance := instance
+
+		hooks.start(instance.Instance, func() {
+			defer d.close()
+
+			if err := handleTerminating(w, svc, d, instance, nodes, params); err != nil {
+				fmt.Fprintln(w, err)
+			}
+		})
+	}
+
+	return nil
+}
+
+// Helper function to drain the node of a terminating instance, sending heartbeats to extend the lifecycle hooks while
+// the pods are evicted. The hooks are completed even when the drain fails, since the instance is terminated regardless.
+// A drain which is cancelled is rolled back instead, leaving the hooks to be handled in a later cycle or after a restart.
+func handleTerminating(w io.Writer, svc *autoscaling.AutoScaling, d *drainer, instance terminating, nodes []corev1.Node, params WatchParams) error {
+	var node *corev1.Node
+
+	for i := range nodes {
+		if instanceID(nodes[i]) == instance.Instance {
+			node = &nodes[i]
+			break
+		}
+	}
+
+	if node != nil {
+		name := node.ObjectMeta.Name
+
+		done := make(chan struct{})
+		go recordHeartbeats(d.ctx, w, svc, instance, params.LifecycleHeartbeat, done)
+
+		err := d.drain(w, name)
+
+		close(done)
+
+		if err != nil && d.cancelled() {
+			d.undo(w, name, err)
+			return errors.Wrapf(err, "stopped handling lifecycle hooks for instance %s because: the drain was cancelled", instance.Instance)
+		}
+
+		if err != nil {
+			fmt.Fprintf(w, "WARNING: Failed to drain node %s before its instance is terminated: %s\n", name, err)
+		}
+	}
+
+	for _, hook := range instance.Hooks {
+		fmt.Fprintf(w, "Completing lifecycle hook %s for instance %s\n", hook, instance.Instance)
+
+		_, err := svc.CompleteLifecycleActionWithContext(d.ctx, &autoscaling.CompleteLifecycleActionInput{
+			AutoScalingGroupName:  aws.String(instance.Group),
+			InstanceId:            aws.String(instance.Instance),
+			LifecycleHookName:     aws.String(hook),
+			LifecycleActionResult: aws.String(LifecycleActionContinue),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to complete lifecycle hook %s for instance %s", hook, instance.Instance)
+		}
+	}
+
+	return nil
+}
+
+// Helper function to extend the lifecycle hooks of an instance until done is closed.
+func recordHeartbeats(ctx context.Context, w io.Writer, svc *autoscaling.AutoScaling, instance terminating, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			for _, hook := range instance.Hooks {
+				_, err := svc.RecordLifecycleActionHeartbeatWithContext(ctx, &autoscaling.RecordLifecycleActionHeartbeatInput{
+					AutoScalingGroupName: aws.String(instance.Group),
+					InstanceId:           aws.String(instance.Instance),
+					LifecycleHookName:    aws.String(hook),
+				})
+				if err != nil {
+					fmt.Fprintf(w, "WARNING: Failed to record heartbeat for lifecycle hook %s of instance %s: %s\n", hook, instance.Instance, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package scaler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// metrics which are served in the Prometheus text format.
+type metrics struct {
+	mu sync.Mutex
+	// Leader is whether this replica is acting as the leader.
+	Leader bool
+	// Cycles which have completed.
+	Cycles int
+	// Failures is the number of cycles which have failed.
+	Failures int
+	// Hold is whether changes are held after consecutive failures.
+	Hold bool
+	// Desired capacity of each group which was calculated in the last cycle.
+	Desired map[string]int64
+	// Current capacity of each group which was observed in the last cycle.
+	Current map[string]int64
+}
+
+// Helper function to create the metrics.
+func newMetrics() *metrics {
+	return &metrics{
+		Desired: make(map[string]int64),
+		Current: make(map[string]int64),
+	}
+}
+
+// Helper function to update the metrics while holding the lock.
+func (m *metrics) update(fn func(m *metrics)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fn(m)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	leader, hold := 0, 0
+
+	if m.Leader {
+		leader = 1
+	}
+
+	if m.Hold {
+		hold = 1
+	}
+
+	fmt.Fprintln(w, "# HELP k8s_aws_autoscaler_leader Whether this replica is acting as the leader.")
+	fmt.Fprintln(w, "# TYPE k8s_aws_autoscaler_leader gauge")
+	fmt.Fprintf(w, "k8s_aws_autoscaler_leader %d\n", leader)
+
+	fmt.Fprintln(w, "# HELP k8s_aws_autoscaler_cycles_total Cycles which have completed.")
+	fmt.Fprintln(w, "# TYPE k8s_aws_autoscaler_cycles_total counter")
+	fmt.Fprintf(w, "k8s_aws_autoscaler_cycles_total %d\n", m.Cycles)
+
+	fmt.Fprintln(w, "# HELP k8s_aws_autoscaler_failures_total Cycles which have failed.")
+	fmt.Fprintln(w, "# TYPE k8s_aws_autoscaler_failures_total counter")
+	fmt.Fprintf(w, "k8s_aws_autoscaler_failures_total %d\n", m.Failures)
+
+	fmt.Fprintln(w, "# HELP k8s_aws_autoscaler_hold Whether changes are held after consecutive failures.")
+	fmt.Fprintln(w, "# TYPE k8s_aws_autoscaler_hold gauge")
+	fmt.Fprintf(w, "k8s_aws_autoscaler_hold %d\n", hold)
+
+	writeGroupGauge(w, "k8s_aws_autoscaler_desired_capacity", "Desired capacity of the group calculated in the last cycle.", m.Desired)
+	writeGroupGauge(w, "k8s_aws_autoscaler_current_capacity", "Current capacity of the group observed in the last cycle.", m.Current)
+}
+
+// Helper function to write a gauge which has a value for each group, ordered by group name.
+func writeGroupGauge(w http.ResponseWriter, name, help string, values map[string]int64) {
+	var groups []string
+
+	for group := range values {
+		groups = append(groups, group)
+	}
+
+	sort.Strings(groups)
+
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+
+	for _, group := range groups {
+		fmt.Fprintf(w, "%s{group=%q} %d\n", name, group, values[group])
+	}
+}
+
+// Helper function to serve the health and metrics endpoints, which all replicas serve whether or not they are the leader.
+func serveEndpoints(address string, m *metrics) error {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.Handle("/metrics", m)
+
+	return http.ListenAndServe(address, mux)
+}
@@ -0,0 +1,48 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Helper function to list the DaemonSets which run on the cluster's nodes.
+func listDaemonSets(k8s *kubernetes.Clientset) ([]appsv1.DaemonSet, error) {
+	sets, err := k8s.AppsV1().DaemonSets(corev1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return sets.Items, nil
+}
+
+// Helper function to calculate how much of each node is consumed by DaemonSets.
+// Each of the group's nodes is checked against the DaemonSet selectors and tolerations, and the
+// largest overhead of each resource is used.
+func getDaemonSetOverhead(w io.Writer, sets []appsv1.DaemonSet, templates []nodeTemplate) resources {
+	var overhead resources
+
+	for _, template := range templates {
+		var total resources
+
+		for _, set := range sets {
+			if !template.schedulable(set.Spec.Template.Spec) {
+				continue
+			}
+
+			cpu, mem := podRequests(set.Spec.Template.Spec)
+
+			total = total.add(resources{CPU: cpu, Memory: mem, Pods: 1})
+		}
+
+		overhead = overhead.max(total)
+	}
+
+	fmt.Fprintf(w, "DaemonSets consume the following amount on each node CPU %d / Memory %d / Pods %d\n", overhead.CPU, overhead.Memory, overhead.Pods)
+
+	return overhead
+}
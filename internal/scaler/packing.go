@@ -0,0 +1,130 @@
+package scaler
+
+import (
+	"sort"
+)
+
+const (
+	// StrategyBinPack simulates placing each pod onto nodes.
+	StrategyBinPack = "binpack"
+	// StrategyAggregate divides the total requests by the size of a node.
+	StrategyAggregate = "aggregate"
+)
+
+// resources is an amount of compute, either requested by a pod or offered by a node.
+type resources struct {
+	// CPU in millicores.
+	CPU int
+	// Memory in megabytes.
+	Memory int
+	// Pods is the number of pods.
+	Pods int
+}
+
+// Helper function to add up a list of resources.
+func sumResources(list []resources) resources {
+	var total resources
+
+	for _, r := range list {
+		total = total.add(r)
+	}
+
+	return total
+}
+
+// Helper function to determine if a request fits into the available resources.
+func (r resources) fits(available resources) bool {
+	return r.CPU <= available.CPU && r.Memory <= available.Memory && r.Pods <= available.Pods
+}
+
+// Helper function to add one set of resources to another.
+func (r resources) add(other resources) resources {
+	return resources{
+		CPU:    r.CPU + other.CPU,
+		Memory: r.Memory + other.Memory,
+		Pods:   r.Pods + other.Pods,
+	}
+}
+
+// Helper function to subtract one set of resources from another.
+func (r resources) sub(other resources) resources {
+	return resources{
+		CPU:    r.CPU - other.CPU,
+		Memory: r.Memory - other.Memory,
+		Pods:   r.Pods - other.Pods,
+	}
+}
+
+// Helper function which returns the larger amount of each resource.
+func (r resources) max(other resources) resources {
+	if other.CPU > r.CPU {
+		r.CPU = other.CPU
+	}
+
+	if other.Memory > r.Memory {
+		r.Memory = other.Memory
+	}
+
+	if other.Pods > r.Pods {
+		r.Pods = other.Pods
+	}
+
+	return r
+}
+
+// Helper function which returns the largest share of a node a request consumes.
+// This is used to order pods from biggest to smallest, regardless of whether they are cpu or memory heavy.
+func (r resources) share(node resources) float64 {
+	var largest float64
+
+	for _, s := range []float64{
+		float64(r.CPU) / float64(node.CPU),
+		float64(r.Memory) / float64(node.Memory),
+		float64(r.Pods) / float64(node.Pods),
+	} {
+		if s > largest {
+			largest = s
+		}
+	}
+
+	return largest
+}
+
+// Helper function which simulates placing pods onto nodes using a first-fit-decreasing strategy.
+// Returns the number of nodes required and the pods which would not fit onto an empty node.
+func packNodes(pods []resources, node resources) (int, []resources) {
+	var (
+		sorted      = make([]resources, len(pods))
+		free        []resources
+		unplaceable []resources
+	)
+
+	copy(sorted, pods)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].share(node) > sorted[j].share(node)
+	})
+
+	for _, pod := range sorted {
+		if !pod.fits(node) {
+			unplaceable = append(unplaceable, pod)
+			continue
+		}
+
+		placed := false
+
+		for i := range free {
+			if pod.fits(free[i]) {
+				free[i] = free[i].sub(pod)
+				placed = true
+				break
+			}
+		}
+
+		if !placed {
+			free = append(free, node.sub(pod))
+		}
+	}
+
+	return len(free), unplaceable
+}
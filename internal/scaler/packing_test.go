@@ -0,0 +1,139 @@
+package scaler
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+// Helper function to repeat a request.
+func repeat(r resources, n int) []resources {
+	list := make([]resources, n)
+
+	for i := range list {
+		list[i] = r
+	}
+
+	return list
+}
+
+func TestPackNodes(t *testing.T) {
+	node := resources{CPU: 1000, Memory: 1000, Pods: 10}
+
+	for _, tc := range []struct {
+		name        string
+		pods        []resources
+		nodes       int
+		unplaceable int
+	}{
+		{
+			name: "no pods",
+		},
+		{
+			name:  "pods which fill a node exactly",
+			pods:  repeat(resources{CPU: 250, Memory: 250, Pods: 1}, 4),
+			nodes: 1,
+		},
+		{
+			name:  "pods which spill onto another node",
+			pods:  repeat(resources{CPU: 250, Memory: 250, Pods: 1}, 5),
+			nodes: 2,
+		},
+		{
+			// Pods which would fit by the total requests are packed by whole pods.
+			name:  "pods which don't split across nodes",
+			pods:  repeat(resources{CPU: 600, Memory: 100, Pods: 1}, 3),
+			nodes: 3,
+		},
+		{
+			// Placing the biggest pods first fits the small pods into the gaps.
+			name: "biggest pods first",
+			pods: []resources{
+				{CPU: 300, Memory: 100, Pods: 1},
+				{CPU: 300, Memory: 100, Pods: 1},
+				{CPU: 700, Memory: 100, Pods: 1},
+				{CPU: 700, Memory: 100, Pods: 1},
+			},
+			nodes: 2,
+		},
+		{
+			// Pods are ordered by their largest share of a node, so memory heavy pods are placed first too.
+			name: "cpu and memory heavy pods",
+			pods: []resources{
+				{CPU: 100, Memory: 300, Pods: 1},
+				{CPU: 700, Memory: 100, Pods: 1},
+				{CPU: 100, Memory: 700, Pods: 1},
+				{CPU: 300, Memory: 100, Pods: 1},
+			},
+			nodes: 2,
+		},
+		{
+			name:  "limited by pods per node",
+			pods:  repeat(resources{CPU: 1, Memory: 1, Pods: 1}, 25),
+			nodes: 3,
+		},
+		{
+			name: "pods larger than a node",
+			pods: []resources{
+				{CPU: 1500, Memory: 100, Pods: 1},
+				{CPU: 100, Memory: 1500, Pods: 1},
+				{CPU: 500, Memory: 500, Pods: 1},
+			},
+			nodes:       1,
+			unplaceable: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			nodes, unplaceable := packNodes(tc.pods, node)
+
+			if nodes != tc.nodes {
+				t.Errorf("expected %d nodes, got %d", tc.nodes, nodes)
+			}
+
+			if len(unplaceable) != tc.unplaceable {
+				t.Errorf("expected %d unplaceable pods, got %d", tc.unplaceable, len(unplaceable))
+			}
+		})
+	}
+}
+
+func TestGetDesired(t *testing.T) {
+	var (
+		node = resources{CPU: 1000, Memory: 1000, Pods: 10}
+		pods = repeat(resources{CPU: 600, Memory: 100, Pods: 1}, 3)
+	)
+
+	for _, tc := range []struct {
+		strategy string
+		desired  int64
+	}{
+		// The aggregate strategy divides the total requests, so it ignores that the pods don't split across nodes.
+		{StrategyAggregate, 2},
+		{StrategyBinPack, 3},
+	} {
+		t.Run(tc.strategy, func(t *testing.T) {
+			if desired := getDesired(ioutil.Discard, tc.strategy, pods, node); desired != tc.desired {
+				t.Errorf("expected %d nodes, got %d", tc.desired, desired)
+			}
+		})
+	}
+}
+
+func TestGetDesiredAggregate(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		cpu, mem         int
+		nodeCPU, nodeMem int
+		desired          int64
+	}{
+		{"nothing requested", 0, 0, 1000, 1000, 0},
+		{"rounded up", 1001, 100, 1000, 1000, 2},
+		{"cpu bound", 3000, 1000, 1000, 1000, 3},
+		{"memory bound", 1000, 3000, 1000, 1000, 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if desired := getDesiredAggregate(tc.cpu, tc.mem, tc.nodeCPU, tc.nodeMem); desired != tc.desired {
+				t.Errorf("expected %d nodes, got %d", tc.desired, desired)
+			}
+		})
+	}
+}
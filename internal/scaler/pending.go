@@ -0,0 +1,161 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// unschedulableReason classifies why the scheduler could not place a pod.
+type unschedulableReason string
+
+const (
+	// reasonInsufficient means the pod would fit if there were more nodes.
+	reasonInsufficient unschedulableReason = "insufficient resources"
+	// reasonMismatch means the pod does not match the nodes eg. selectors or taints.
+	reasonMismatch unschedulableReason = "node mismatch"
+	// reasonUnknown means the scheduler message could not be classified.
+	reasonUnknown unschedulableReason = "unknown"
+)
+
+// Messages reported by the scheduler when a node does not have enough room for a pod.
+var insufficientMessages = []string{
+	"Insufficient cpu",
+	"Insufficient memory",
+	"Insufficient pods",
+	"no nodes available to schedule pods",
+}
+
+// Messages reported by the scheduler when a pod is not allowed to run on a node.
+var mismatchMessages = []string{
+	"didn't match node selector",
+	"MatchNodeSelector",
+	"taints that the pod didn't tolerate",
+	"PodToleratesNodeTaints",
+}
+
+// Helper function which returns the pods which could not be scheduled because of insufficient resources.
+func getUnschedulablePods(w io.Writer, k8s *kubernetes.Clientset, filter FilterParams) ([]corev1.Pod, error) {
+	pods, err := k8s.CoreV1().Pods(corev1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("status.phase", string(corev1.PodPending)).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var unschedulable []corev1.Pod
+
+	for _, pod := range pods.Items {
+		if !filter.matches(pod.ObjectMeta.Namespace, pod.ObjectMeta.Labels) || pod.ObjectMeta.Annotations[AnnotationIgnore] == "true" {
+			continue
+		}
+
+		message, ok := unschedulableMessage(pod)
+		if !ok {
+			continue
+		}
+
+		// The condition message is not always populated, so we fall back to the scheduler events.
+		if message == "" {
+			message, err = failedSchedulingMessage(k8s, pod)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		reason := classifyUnschedulable(message)
+		if reason != reasonInsufficient {
+			fmt.Fprintf(w, "Ignoring unschedulable pod %s/%s because: %s\n", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, reason)
+			continue
+		}
+
+		unschedulable = append(unschedulable, pod)
+	}
+
+	return unschedulable, nil
+}
+
+// Helper function to determine if a pod has been marked as unschedulable, along with the scheduler message.
+func unschedulableMessage(pod corev1.Pod) (string, bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodScheduled {
+			continue
+		}
+
+		if condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			return condition.Message, true
+		}
+	}
+
+	return "", false
+}
+
+// Helper function to lookup the message of the most recent FailedScheduling event for a pod.
+func failedSchedulingMessage(k8s *kubernetes.Clientset, pod corev1.Pod) (string, error) {
+	events, err := k8s.CoreV1().Events(pod.ObjectMeta.Namespace).List(metav1.ListOptions{
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermEqualSelector("involvedObject.kind", "Pod"),
+			fields.OneTermEqualSelector("involvedObject.name", pod.ObjectMeta.Name),
+			fields.OneTermEqualSelector("reason", "FailedScheduling"),
+		).String(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var latest *corev1.Event
+
+	for i, event := range events.Items {
+		if latest == nil || latest.LastTimestamp.Before(&event.LastTimestamp) {
+			latest = &events.Items[i]
+		}
+	}
+
+	if latest == nil {
+		return "", nil
+	}
+
+	return latest.Message, nil
+}
+
+// Helper function to classify the scheduler message for an unschedulable pod.
+// A pod which is short on resources on some nodes will fit on a new node, even if it also
+// does not match other nodes in the cluster.
+func classifyUnschedulable(message string) unschedulableReason {
+	for _, m := range insufficientMessages {
+		if strings.Contains(message, m) {
+			return reasonInsufficient
+		}
+	}
+
+	for _, m := range mismatchMessages {
+		if strings.Contains(message, m) {
+			return reasonMismatch
+		}
+	}
+
+	return reasonUnknown
+}
+
+// Helper function which returns the capacity needed to fit the unschedulable pods onto new nodes.
+// Instances which have been requested but have not registered as nodes yet are counted towards the
+// new nodes, so pods which are waiting for them don't raise the capacity again on the next cycle.
+func pendingFloor(pending []resources, node resources, current int64, registered int) (int64, int) {
+	nodes, _ := packNodes(pending, node)
+
+	booting := current - int64(registered)
+	if booting < 0 {
+		booting = 0
+	}
+
+	if needed := int64(nodes) - booting; needed > 0 {
+		return current + needed, nodes
+	}
+
+	return current, nodes
+}
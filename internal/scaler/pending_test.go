@@ -0,0 +1,36 @@
+package scaler
+
+import "testing"
+
+func TestPendingFloor(t *testing.T) {
+	var (
+		node = resources{CPU: 1000, Memory: 1000, Pods: 10}
+		// The pods need 2 new nodes.
+		pending = repeat(resources{CPU: 600, Memory: 100, Pods: 1}, 2)
+	)
+
+	for _, tc := range []struct {
+		name       string
+		current    int64
+		registered int
+		floor      int64
+	}{
+		{"no instances booting", 3, 3, 5},
+		{"pods still pending while the new instances boot", 5, 3, 5},
+		{"some of the new instances are booting", 4, 3, 5},
+		{"more instances booting than the pods need", 8, 3, 8},
+		{"more nodes than the desired capacity", 3, 4, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			floor, nodes := pendingFloor(pending, node, tc.current, tc.registered)
+
+			if floor != tc.floor {
+				t.Errorf("expected a floor of %d, got %d", tc.floor, floor)
+			}
+
+			if nodes != 2 {
+				t.Errorf("expected 2 nodes, got %d", nodes)
+			}
+		})
+	}
+}
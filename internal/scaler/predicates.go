@@ -0,0 +1,229 @@
+package scaler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// TagLabelPrefix declares a label of the group's nodes on the autoscaling group.
+	//   eg. k8s.io/cluster-autoscaler/node-template/label/role=web
+	TagLabelPrefix = "k8s.io/cluster-autoscaler/node-template/label/"
+	// TagTaintPrefix declares a taint of the group's nodes on the autoscaling group.
+	//   eg. k8s.io/cluster-autoscaler/node-template/taint/dedicated=web:NoSchedule
+	TagTaintPrefix = "k8s.io/cluster-autoscaler/node-template/taint/"
+)
+
+// nodeTemplate describes the labels and taints of a node which pods are scheduled against.
+type nodeTemplate struct {
+	Labels map[string]string
+	Taints []corev1.Taint
+}
+
+// Helper function to build a template from a registered Node.
+// The taint added while a node is being drained is ignored, since it doesn't describe the group's nodes.
+func templateFromNode(node corev1.Node) nodeTemplate {
+	template := nodeTemplate{
+		Labels: node.ObjectMeta.Labels,
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != TaintScaleDown {
+			template.Taints = append(template.Taints, taint)
+		}
+	}
+
+	return template
+}
+
+// Helper function to build a template from the node-template tags on an autoscaling group.
+func templateFromTags(tags []*autoscaling.TagDescription) nodeTemplate {
+	template := nodeTemplate{
+		Labels: make(map[string]string),
+	}
+
+	for _, tag := range tags {
+		if tag.Key == nil || tag.Value == nil {
+			continue
+		}
+
+		if strings.HasPrefix(*tag.Key, TagLabelPrefix) {
+			template.Labels[strings.TrimPrefix(*tag.Key, TagLabelPrefix)] = *tag.Value
+		}
+
+		if strings.HasPrefix(*tag.Key, TagTaintPrefix) {
+			taint := corev1.Taint{
+				Key:    strings.TrimPrefix(*tag.Key, TagTaintPrefix),
+				Value:  *tag.Value,
+				Effect: corev1.TaintEffectNoSchedule,
+			}
+
+			if i := strings.LastIndex(*tag.Value, ":"); i >= 0 {
+				taint.Value = (*tag.Value)[:i]
+				taint.Effect = corev1.TaintEffect((*tag.Value)[i+1:])
+			}
+
+			template.Taints = append(template.Taints, taint)
+		}
+	}
+
+	return template
+}
+
+// Helper function to describe the nodes pods will be scheduled against in the group.
+// Registered Nodes are used when available, otherwise the group's node-template tags.
+func getGroupTemplates(asg *autoscaling.Group, nodes []corev1.Node) []nodeTemplate {
+	if len(nodes) == 0 {
+		return []nodeTemplate{templateFromTags(asg.Tags)}
+	}
+
+	var templates []nodeTemplate
+
+	for _, node := range nodes {
+		templates = append(templates, templateFromNode(node))
+	}
+
+	return templates
+}
+
+// Helper function to determine if a pod could be scheduled onto any of the nodes, ignoring resources.
+func schedulableOnAny(templates []nodeTemplate, spec corev1.PodSpec) bool {
+	for _, template := range templates {
+		if template.schedulable(spec) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper function to determine if a pod could be scheduled onto a node, ignoring resources.
+func (t nodeTemplate) schedulable(spec corev1.PodSpec) bool {
+	return t.matchesNodeSelector(spec) && t.matchesNodeAffinity(spec) && t.toleratedBy(spec)
+}
+
+// Helper function to determine if the node has every label in the pod's nodeSelector.
+func (t nodeTemplate) matchesNodeSelector(spec corev1.PodSpec) bool {
+	for key, value := range spec.NodeSelector {
+		if actual, ok := t.Labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Helper function to determine if the node satisfies the pod's required node affinity.
+// The terms are ORed, while the expressions within a term are ANDed.
+func (t nodeTemplate) matchesNodeAffinity(spec corev1.PodSpec) bool {
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil {
+		return true
+	}
+
+	required := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		return true
+	}
+
+	for _, term := range required.NodeSelectorTerms {
+		if t.matchesTerm(term) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper function to determine if the node satisfies every expression in a node selector term.
+func (t nodeTemplate) matchesTerm(term corev1.NodeSelectorTerm) bool {
+	if len(term.MatchExpressions) == 0 {
+		return false
+	}
+
+	for _, expression := range term.MatchExpressions {
+		if !t.matchesRequirement(expression) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Helper function to determine if the node satisfies a single node selector requirement.
+func (t nodeTemplate) matchesRequirement(requirement corev1.NodeSelectorRequirement) bool {
+	value, exists := t.Labels[requirement.Key]
+
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpIn:
+		return exists && contains(requirement.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !exists || !contains(requirement.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !exists || len(requirement.Values) != 1 {
+			return false
+		}
+
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+
+		expected, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+
+		if requirement.Operator == corev1.NodeSelectorOpGt {
+			return actual > expected
+		}
+
+		return actual < expected
+	}
+
+	return false
+}
+
+// Helper function to determine if the pod tolerates every taint which would stop it being scheduled.
+func (t nodeTemplate) toleratedBy(spec corev1.PodSpec) bool {
+	for i := range t.Taints {
+		taint := &t.Taints[i]
+
+		// PreferNoSchedule taints don't stop a pod from being scheduled.
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+
+		tolerated := false
+
+		for j := range spec.Tolerations {
+			if spec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+
+		if !tolerated {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Helper function to determine if a list of strings contains a value.
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,217 @@
+package scaler
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Helper function to build a pod spec which requires node affinity, with each term ORed.
+func requireAffinity(terms ...[]corev1.NodeSelectorRequirement) corev1.PodSpec {
+	required := &corev1.NodeSelector{}
+
+	for _, expressions := range terms {
+		required.NodeSelectorTerms = append(required.NodeSelectorTerms, corev1.NodeSelectorTerm{
+			MatchExpressions: expressions,
+		})
+	}
+
+	return corev1.PodSpec{
+		Affinity: &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: required,
+			},
+		},
+	}
+}
+
+// Helper function to build a node selector requirement.
+func requirement(key string, operator corev1.NodeSelectorOperator, values ...string) []corev1.NodeSelectorRequirement {
+	return []corev1.NodeSelectorRequirement{
+		{
+			Key:      key,
+			Operator: operator,
+			Values:   values,
+		},
+	}
+}
+
+func TestNodeTemplateSchedulable(t *testing.T) {
+	var (
+		web = nodeTemplate{
+			Labels: map[string]string{
+				"role": "web",
+				"cpus": "8",
+			},
+		}
+		dedicated = nodeTemplate{
+			Taints: []corev1.Taint{
+				{Key: "dedicated", Value: "web", Effect: corev1.TaintEffectNoSchedule},
+			},
+		}
+		preferred = nodeTemplate{
+			Taints: []corev1.Taint{
+				{Key: "dedicated", Value: "web", Effect: corev1.TaintEffectPreferNoSchedule},
+			},
+		}
+	)
+
+	for _, tc := range []struct {
+		name        string
+		template    nodeTemplate
+		spec        corev1.PodSpec
+		schedulable bool
+	}{
+		{"no constraints", web, corev1.PodSpec{}, true},
+		{"node selector matches", web, corev1.PodSpec{NodeSelector: map[string]string{"role": "web"}}, true},
+		{"node selector value doesn't match", web, corev1.PodSpec{NodeSelector: map[string]string{"role": "api"}}, false},
+		{"node selector label missing", web, corev1.PodSpec{NodeSelector: map[string]string{"zone": "a"}}, false},
+		{"node selector requires every label", web, corev1.PodSpec{NodeSelector: map[string]string{"role": "web", "zone": "a"}}, false},
+		{"In matches", web, requireAffinity(requirement("role", corev1.NodeSelectorOpIn, "api", "web")), true},
+		{"In doesn't match", web, requireAffinity(requirement("role", corev1.NodeSelectorOpIn, "api")), false},
+		{"In label missing", web, requireAffinity(requirement("zone", corev1.NodeSelectorOpIn, "a")), false},
+		{"NotIn matches", web, requireAffinity(requirement("role", corev1.NodeSelectorOpNotIn, "api")), true},
+		{"NotIn doesn't match", web, requireAffinity(requirement("role", corev1.NodeSelectorOpNotIn, "web")), false},
+		{"NotIn label missing", web, requireAffinity(requirement("zone", corev1.NodeSelectorOpNotIn, "a")), true},
+		{"Exists matches", web, requireAffinity(requirement("role", corev1.NodeSelectorOpExists)), true},
+		{"Exists label missing", web, requireAffinity(requirement("zone", corev1.NodeSelectorOpExists)), false},
+		{"DoesNotExist matches", web, requireAffinity(requirement("zone", corev1.NodeSelectorOpDoesNotExist)), true},
+		{"DoesNotExist label exists", web, requireAffinity(requirement("role", corev1.NodeSelectorOpDoesNotExist)), false},
+		{"Gt matches", web, requireAffinity(requirement("cpus", corev1.NodeSelectorOpGt, "4")), true},
+		{"Gt doesn't match", web, requireAffinity(requirement("cpus", corev1.NodeSelectorOpGt, "8")), false},
+		{"Gt label isn't a number", web, requireAffinity(requirement("role", corev1.NodeSelectorOpGt, "4")), false},
+		{"Gt value isn't a number", web, requireAffinity(requirement("cpus", corev1.NodeSelectorOpGt, "four")), false},
+		{"Lt matches", web, requireAffinity(requirement("cpus", corev1.NodeSelectorOpLt, "16")), true},
+		{"Lt doesn't match", web, requireAffinity(requirement("cpus", corev1.NodeSelectorOpLt, "8")), false},
+		{"Lt label missing", web, requireAffinity(requirement("memory", corev1.NodeSelectorOpLt, "16")), false},
+		{
+			name:     "expressions within a term are ANDed",
+			template: web,
+			spec: requireAffinity(append(
+				requirement("role", corev1.NodeSelectorOpIn, "web"),
+				requirement("zone", corev1.NodeSelectorOpExists)...,
+			)),
+			schedulable: false,
+		},
+		{
+			name:     "terms are ORed",
+			template: web,
+			spec: requireAffinity(
+				requirement("zone", corev1.NodeSelectorOpExists),
+				requirement("role", corev1.NodeSelectorOpIn, "web"),
+			),
+			schedulable: true,
+		},
+		{
+			name:        "no term matches",
+			template:    web,
+			spec:        requireAffinity(requirement("zone", corev1.NodeSelectorOpExists), requirement("role", corev1.NodeSelectorOpIn, "api")),
+			schedulable: false,
+		},
+		{
+			name:        "node selector and affinity must both match",
+			template:    web,
+			spec:        corev1.PodSpec{NodeSelector: map[string]string{"role": "api"}, Affinity: requireAffinity(requirement("role", corev1.NodeSelectorOpIn, "web")).Affinity},
+			schedulable: false,
+		},
+		{"taint not tolerated", dedicated, corev1.PodSpec{}, false},
+		{
+			name:     "taint tolerated",
+			template: dedicated,
+			spec: corev1.PodSpec{
+				Tolerations: []corev1.Toleration{
+					{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "web", Effect: corev1.TaintEffectNoSchedule},
+				},
+			},
+			schedulable: true,
+		},
+		{
+			name:     "taint tolerated by exists",
+			template: dedicated,
+			spec: corev1.PodSpec{
+				Tolerations: []corev1.Toleration{
+					{Key: "dedicated", Operator: corev1.TolerationOpExists},
+				},
+			},
+			schedulable: true,
+		},
+		{
+			name:     "taint with another value not tolerated",
+			template: dedicated,
+			spec: corev1.PodSpec{
+				Tolerations: []corev1.Toleration{
+					{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "api", Effect: corev1.TaintEffectNoSchedule},
+				},
+			},
+			schedulable: false,
+		},
+		{
+			name:     "taint with another effect not tolerated",
+			template: dedicated,
+			spec: corev1.PodSpec{
+				Tolerations: []corev1.Toleration{
+					{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "web", Effect: corev1.TaintEffectNoExecute},
+				},
+			},
+			schedulable: false,
+		},
+		{"PreferNoSchedule taint doesn't need tolerating", preferred, corev1.PodSpec{}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if schedulable := tc.template.schedulable(tc.spec); schedulable != tc.schedulable {
+				t.Errorf("expected schedulable %t, got %t", tc.schedulable, schedulable)
+			}
+		})
+	}
+}
+
+func TestTemplateFromTags(t *testing.T) {
+	tags := []*autoscaling.TagDescription{
+		{Key: aws.String("Name"), Value: aws.String("nodes")},
+		{Key: aws.String(TagLabelPrefix + "role"), Value: aws.String("web")},
+		{Key: aws.String(TagLabelPrefix + "node.kubernetes.io/lifecycle"), Value: aws.String("spot")},
+		{Key: aws.String(TagTaintPrefix + "dedicated"), Value: aws.String("web:NoSchedule")},
+		{Key: aws.String(TagTaintPrefix + "spot"), Value: aws.String("true:PreferNoSchedule")},
+		{Key: aws.String(TagTaintPrefix + "gpu"), Value: aws.String("true")},
+		{Key: aws.String(TagTaintPrefix + "url"), Value: aws.String("http://example.com:NoExecute")},
+		{Key: aws.String(TagLabelPrefix + "missing")},
+	}
+
+	template := templateFromTags(tags)
+
+	labels := map[string]string{
+		"role":                         "web",
+		"node.kubernetes.io/lifecycle": "spot",
+	}
+
+	if len(template.Labels) != len(labels) {
+		t.Errorf("expected labels %v, got %v", labels, template.Labels)
+	}
+
+	for key, value := range labels {
+		if template.Labels[key] != value {
+			t.Errorf("expected label %s=%s, got %v", key, value, template.Labels)
+		}
+	}
+
+	taints := []corev1.Taint{
+		{Key: "dedicated", Value: "web", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "spot", Value: "true", Effect: corev1.TaintEffectPreferNoSchedule},
+		// The effect defaults to NoSchedule.
+		{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+		// Only the last colon separates the effect.
+		{Key: "url", Value: "http://example.com", Effect: corev1.TaintEffectNoExecute},
+	}
+
+	if len(template.Taints) != len(taints) {
+		t.Fatalf("expected taints %v, got %v", taints, template.Taints)
+	}
+
+	for i, taint := range taints {
+		if template.Taints[i] != taint {
+			t.Errorf("expected taint %v, got %v", taint, template.Taints[i])
+		}
+	}
+}
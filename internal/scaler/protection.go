@@ -0,0 +1,104 @@
+package scaler
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/pkg/errors"
+)
+
+// The maximum amount of instances which can be passed to SetInstanceProtection.
+const protectionBatchSize = 50
+
+// Helper function to protect the instances of nodes which can't be removed from scale in, so a manual change to the
+// desired capacity or a rebalance can't terminate them. Nodes can't be removed when they run pods which can't be
+// evicted, or have the scale down disabled annotation. Protection is lifted from nodes which can be removed again,
+// otherwise it is only lifted once a node has been chosen for removal and drained.
+func protectInstances(ctx context.Context, w io.Writer, svc *autoscaling.AutoScaling, g *group, candidates []candidate, dry bool) error {
+	var protect, unprotect []string
+
+	for _, c := range candidates {
+		var (
+			id   = instanceID(c.Node)
+			name = c.Node.ObjectMeta.Name
+		)
+
+		if c.Blocker != "" && !g.protected(id) {
+			fmt.Fprintf(w, "Protecting instance %s of node %s from scale in because: %s\n", id, name, c.Blocker)
+			protect = append(protect, id)
+		}
+
+		if c.Blocker == "" && g.protected(id) {
+			fmt.Fprintf(w, "Removing scale in protection from instance %s of node %s because: it can be removed\n", id, name)
+			unprotect = append(unprotect, id)
+		}
+	}
+
+	// Don't make any changes. Perfect for debugging.
+	if dry {
+		return nil
+	}
+
+	if len(protect) > 0 {
+		if err := setInstanceProtection(ctx, svc, g.Name, protect, true); err != nil {
+			return errors.Wrap(err, "failed to protect instances")
+		}
+
+		g.setProtected(protect, true)
+	}
+
+	if len(unprotect) > 0 {
+		if err := setInstanceProtection(ctx, svc, g.Name, unprotect, false); err != nil {
+			return errors.Wrap(err, "failed to remove scale in protection")
+		}
+
+		g.setProtected(unprotect, false)
+	}
+
+	return nil
+}
+
+// Helper function to record the scale in protection of the group's instances, so it doesn't have to be looked up again.
+func (g *group) setProtected(ids []string, protected bool) {
+	for _, instance := range g.ASG.Instances {
+		if contains(ids, aws.StringValue(instance.InstanceId)) {
+			instance.ProtectedFromScaleIn = aws.Bool(protected)
+		}
+	}
+}
+
+// Helper function to determine if an instance of the group is protected from scale in.
+func (g *group) protected(id string) bool {
+	for _, instance := range g.ASG.Instances {
+		if aws.StringValue(instance.InstanceId) == id {
+			return aws.BoolValue(instance.ProtectedFromScaleIn)
+		}
+	}
+
+	return false
+}
+
+// Helper function to set the scale in protection of instances, in batches which the API accepts.
+func setInstanceProtection(ctx context.Context, svc *autoscaling.AutoScaling, name string, ids []string, protected bool) error {
+	for start := 0; start < len(ids); start += protectionBatchSize {
+		end := start + protectionBatchSize
+
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		_, err := svc.SetInstanceProtectionWithContext(ctx, &autoscaling.SetInstanceProtectionInput{
+			AutoScalingGroupName: aws.String(name),
+			InstanceIds:          aws.StringSlice(ids[start:end]),
+			ProtectedFromScaleIn: aws.Bool(protected),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package scaler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// RetryParams declares how failures are retried and when the scaler stops making changes.
+type RetryParams struct {
+	// Attempts which are made for each call before the cycle fails.
+	Attempts int
+	// Backoff before the first retry, which doubles on each retry.
+	Backoff time.Duration
+	// MaxBackoff between retries.
+	MaxBackoff time.Duration
+	// HoldAfter this many consecutive failed cycles no changes are made until a cycle which makes changes succeeds.
+	// Changes are tried again after this many held cycles have succeeded. Disabled when zero.
+	HoldAfter int
+}
+
+// Helper function to determine if an error is transient eg. throttling, a server error, a timeout or a dropped connection.
+// Other errors are fatal and won't succeed by retrying eg. access denied or a validation error.
+func retryable(err error) bool {
+	err = errors.Cause(err)
+
+	if request.IsErrorRetryable(err) || request.IsErrorThrottle(err) {
+		return true
+	}
+
+	if failure, ok := err.(awserr.RequestFailure); ok && failure.StatusCode() >= 500 {
+		return true
+	}
+
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsServiceUnavailable(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Code >= 500 {
+		return true
+	}
+
+	if netErr, ok := err.(net.Error); ok && (netErr.Timeout() || netErr.Temporary()) {
+		return true
+	}
+
+	return connectionError(err)
+}
+
+// Helper function to determine if an error is a refused or dropped connection, which happens while an API server or
+// load balancer restarts.
+func connectionError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+
+	if sysErr, ok := err.(*os.SyscallError); ok {
+		err = sysErr.Err
+	}
+
+	return err == syscall.ECONNREFUSED || err == syscall.ECONNRESET
+}
+
+// Helper function to call a function, retrying transient errors with a jittered exponential backoff.
+// Stops retrying once the context is cancelled.
+func retry(ctx context.Context, w io.Writer, params RetryParams, description string, fn func() error) error {
+	backoff := params.Backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if !retryable(err) || attempt >= params.Attempts || ctx.Err() != nil {
+			return err
+		}
+
+		// Half of the backoff is random, so replicas and groups don't retry in lockstep.
+		sleep := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+		fmt.Fprintf(w, "Retrying %s in %s (attempt %d of %d) because: %s\n", description, sleep.Round(time.Millisecond), attempt+1, params.Attempts, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(sleep):
+		}
+
+		backoff *= 2
+
+		if backoff > params.MaxBackoff {
+			backoff = params.MaxBackoff
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package scaler
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestWatcherHold(t *testing.T) {
+	var (
+		transient = apierrors.NewServiceUnavailable("unavailable")
+		fatal     = errors.New("access denied")
+	)
+
+	// Each step is the outcome of a cycle, nil when it succeeded, followed by whether the scaler holds afterwards.
+	type step struct {
+		err     error
+		holding bool
+	}
+
+	for _, tc := range []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "transient failures hold after the limit",
+			steps: []step{
+				{transient, false},
+				{transient, false},
+				{transient, true},
+			},
+		},
+		{
+			name: "a success resets the failures",
+			steps: []step{
+				{transient, false},
+				{transient, false},
+				{nil, false},
+				{transient, false},
+			},
+		},
+		{
+			name: "fatal failures hold after the limit",
+			steps: []step{
+				{fatal, false},
+				{transient, false},
+				{fatal, true},
+			},
+		},
+		{
+			name: "held cycles don't end the hold",
+			steps: []step{
+				{fatal, false},
+				{fatal, false},
+				{fatal, true},
+				{nil, true},
+				{nil, true},
+				{nil, true},
+			},
+		},
+		{
+			name: "a cycle which makes changes ends the hold",
+			steps: []step{
+				{fatal, false},
+				{fatal, false},
+				{fatal, true},
+				{nil, true},
+				{nil, true},
+				{nil, true},
+				{nil, false},
+			},
+		},
+		{
+			name: "a failed cycle which makes changes continues the hold",
+			steps: []step{
+				{fatal, false},
+				{fatal, false},
+				{fatal, true},
+				{nil, true},
+				{nil, true},
+				{nil, true},
+				{fatal, true},
+				{nil, true},
+				{nil, true},
+				{nil, true},
+				{nil, false},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			wt := &watcher{
+				params: WatchParams{
+					Retry: RetryParams{
+						HoldAfter: 3,
+					},
+				},
+				metrics: newMetrics(),
+			}
+
+			for i, s := range tc.steps {
+				wt.probing = !wt.holds()
+
+				if s.err != nil {
+					wt.fail(ioutil.Discard, s.err)
+				} else {
+					wt.succeed(ioutil.Discard)
+				}
+
+				if wt.holding != s.holding {
+					t.Fatalf("step %d: expected holding %t, got %t", i, s.holding, wt.holding)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"service unavailable", apierrors.NewServiceUnavailable("unavailable"), true},
+		{"too many requests", apierrors.NewTooManyRequests("slow down", 1), true},
+		{"wrapped", errors.Wrap(apierrors.NewServiceUnavailable("unavailable"), "failed to list nodes"), true},
+		{"not found", apierrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, "node"), false},
+		{"aws throttling", awserr.New("Throttling", "rate exceeded", nil), true},
+		{"aws access denied", awserr.NewRequestFailure(awserr.New("AccessDenied", "denied", nil), 403, ""), false},
+		{"aws server error", awserr.NewRequestFailure(awserr.New("InternalFailure", "failure", nil), 500, ""), true},
+		{"connection refused", &url.Error{Op: "Get", URL: "https://10.0.0.1", Err: &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, true},
+		{"connection reset", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, true},
+		{"connection closed", &url.Error{Op: "Get", URL: "https://10.0.0.1", Err: io.EOF}, true},
+		{"connection closed early", errors.Wrap(io.ErrUnexpectedEOF, "failed to list nodes"), true},
+		{"other syscall error", &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.EACCES)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := retryable(tc.err); actual != tc.retryable {
+				t.Errorf("expected retryable %t, got %t", tc.retryable, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package scaler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// candidate is a node which could be removed from a group.
+type candidate struct {
+	Node corev1.Node
+	// Pods which have to be evicted before the node is removed.
+	Pods []corev1.Pod
+	// Requested resources of all the pods running on the node.
+	Requested resources
+	// Utilization is the largest share of the node's allocatable resources requested by its pods.
+	Utilization float64
+	// Blocker is the reason the node can't be removed, empty when it can be.
+	Blocker string
+}
+
+// failedRemovalBackoff is how long a node which could not be removed is left alone, so a node whose pods can't be
+// evicted isn't drained over and over again.
+const failedRemovalBackoff = 30 * time.Minute
+
+// failedRemovals remembers when removing each node last failed.
+type failedRemovals map[string]time.Time
+
+// Helper function to determine if removing a node failed recently.
+func (f failedRemovals) recent(name string, now time.Time) bool {
+	failed, ok := f[name]
+	return ok && now.Sub(failed) < failedRemovalBackoff
+}
+
+// Helper function to forget the failures which are no longer recent.
+func (f failedRemovals) prune(now time.Time) {
+	for name, failed := range f {
+		if now.Sub(failed) >= failedRemovalBackoff {
+			delete(f, name)
+		}
+	}
+}
+
+// Helper function to describe each of the group's nodes, ordered by the lowest requested utilization.
+// Nodes which are cordoned are left out, since they are already being drained or were cordoned by someone else.
+func getCandidates(k8s *kubernetes.Clientset, nodes []corev1.Node) ([]candidate, error) {
+	pdbs, err := k8s.PolicyV1beta1().PodDisruptionBudgets(corev1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list PodDisruptionBudgets")
+	}
+
+	var candidates []candidate
+
+	for _, node := range nodes {
+		name := node.ObjectMeta.Name
+
+		if node.Spec.Unschedulable || hasTaint(node.Spec.Taints, TaintScaleDown) {
+			continue
+		}
+
+		pods, err := podsOnNode(k8s, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list pods on node %s", name)
+		}
+
+		c := candidate{
+			Node:    node,
+			Blocker: nodeBlocker(node),
+		}
+
+		for _, pod := range pods {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+
+			c.Requested = c.Requested.add(podResources(pod))
+
+			if !needsEviction(pod) {
+				continue
+			}
+
+			if reason := evictionBlocker(pod, pdbs.Items); reason != "" && c.Blocker == "" {
+				c.Blocker = fmt.Sprintf("pod %s/%s %s", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name, reason)
+			}
+
+			c.Pods = append(c.Pods, pod)
+		}
+
+		c.Utilization = c.Requested.share(nodeAllocatable(node))
+
+		candidates = append(candidates, c)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Utilization < candidates[j].Utilization
+	})
+
+	return candidates, nil
+}
+
+// Helper function to describe the group's nodes as scale down candidates, which is only done once per cycle.
+func (g *group) candidates(k8s *kubernetes.Clientset) ([]candidate, error) {
+	if g.Candidates != nil {
+		return g.Candidates, nil
+	}
+
+	candidates, err := getCandidates(k8s, g.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	g.Candidates = candidates
+
+	return candidates, nil
+}
+
+// Helper function to determine why a node can't be removed regardless of its pods.
+// Returns an empty string when the node can be removed.
+func nodeBlocker(node corev1.Node) string {
+	if node.ObjectMeta.Annotations[AnnotationScaleDownDisabled] == "true" {
+		return fmt.Sprintf("it has the %s annotation", AnnotationScaleDownDisabled)
+	}
+
+	return ""
+}
+
+// Helper function to determine why a pod stops its node from being removed.
+// Returns an empty string when the pod can be evicted.
+func evictionBlocker(pod corev1.Pod, pdbs []policyv1beta1.PodDisruptionBudget) string {
+	// The Eviction API refuses to evict the pod, so draining its node would only wait for the drain timeout.
+	for _, pdb := range disruptionBudgets(pod, pdbs) {
+		if pdb.Status.PodDisruptionsAllowed == 0 {
+			return fmt.Sprintf("is covered by PodDisruptionBudget %s which allows no disruptions", pdb.ObjectMeta.Name)
+		}
+	}
+
+	switch pod.ObjectMeta.Annotations[AnnotationSafeToEvict] {
+	case "true":
+		return ""
+	case "false":
+		return fmt.Sprintf("has the %s annotation set to \"false\"", AnnotationSafeToEvict)
+	}
+
+	if metav1.GetControllerOf(&pod) == nil {
+		return "is not managed by a controller"
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil || volume.HostPath != nil {
+			return fmt.Sprintf("has local storage (volume %s)", volume.Name)
+		}
+	}
+
+	if pod.ObjectMeta.Namespace == metav1.NamespaceSystem && len(disruptionBudgets(pod, pdbs)) == 0 {
+		return "is a kube-system pod without a PodDisruptionBudget"
+	}
+
+	return ""
+}
+
+// Helper function to return the PodDisruptionBudgets which cover a pod.
+func disruptionBudgets(pod corev1.Pod, pdbs []policyv1beta1.PodDisruptionBudget) []policyv1beta1.PodDisruptionBudget {
+	var matching []policyv1beta1.PodDisruptionBudget
+
+	for _, pdb := range pdbs {
+		if pdb.ObjectMeta.Namespace != pod.ObjectMeta.Namespace {
+			continue
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+
+		if selector.Matches(labels.Set(pod.ObjectMeta.Labels)) {
+			matching = append(matching, pdb)
+		}
+	}
+
+	return matching
+}
+
+// Helper function to remove nodes from a group one at a time.
+// A node is only removed when its pods would fit onto the nodes which remain in the group.
+// Each node is cordoned and drained before its instance is terminated, which also decrements the desired capacity.
+// Nodes which recently failed to be removed are skipped. Returns how many nodes were removed.
+func removeNodes(ctx context.Context, w io.Writer, svc *autoscaling.AutoScaling, k8s *kubernetes.Clientset, d *drainer, g *group, failed failedRemovals, count int64, dry bool) (int64, error) {
+	candidates, err := g.candidates(k8s)
+	if err != nil {
+		return 0, err
+	}
+
+	failed.prune(time.Now())
+
+	var (
+		sim     = newSimulation(candidates)
+		chosen  []candidate
+		removed int64
+	)
+
+	for _, c := range candidates {
+		if int64(len(chosen)) == count {
+			break
+		}
+
+		name := c.Node.ObjectMeta.Name
+
+		if c.Blocker != "" {
+			fmt.Fprintf(w, "Not removing node %s because: %s\n", name, c.Blocker)
+			continue
+		}
+
+		if failed.recent(name, time.Now()) {
+			fmt.Fprintf(w, "Not removing node %s because: removing it failed less than %s ago\n", name, failedRemovalBackoff)
+			continue
+		}
+
+		if blocking := sim.remove(c); len(blocking) > 0 {
+			fmt.Fprintf(w, "Not removing node %s because: pods would not fit onto the remaining nodes\n", name)
+
+			for _, pod := range blocking {
+				fmt.Fprintf(w, "  %s/%s\n", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
+			}
+
+			continue
+		}
+
+		chosen = append(chosen, c)
+	}
+
+	if int64(len(chosen)) < count {
+		fmt.Fprintf(w, "Only %d of the %d nodes can be removed because: the other nodes can't be removed\n", len(chosen), count)
+	}
+
+	for _, c := range chosen {
+		name := c.Node.ObjectMeta.Name
+
+		fmt.Fprintf(w, "Removing node %s with utilization %.0f%% (%d pods to evict)\n", name, c.Utilization*100, len(c.Pods))
+
+		// Don't make any changes. Perfect for debugging.
+		if dry {
+			removed++
+			continue
+		}
+
+		// No more nodes are drained once the cycle is cancelled, the drain which is in flight is allowed to finish.
+		if ctx.Err() != nil {
+			return removed, errors.Errorf("stopped removing nodes because: the cycle was cancelled")
+		}
+
+		if err := removeNode(w, svc, d, g, c.Node); err != nil {
+			failed[name] = time.Now()
+			return removed, errors.Wrapf(err, "failed to remove node %s", name)
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+// Helper function to drain a node and terminate its instance.
+// The node is uncordoned if it could not be drained or its instance could not be terminated,
+// so its pods can be scheduled onto it again.
+func removeNode(w io.Writer, svc *autoscaling.AutoScaling, d *drainer, g *group, node corev1.Node) error {
+	var (
+		name = node.ObjectMeta.Name
+		id   = instanceID(node)
+	)
+
+	if err := d.drain(w, name); err != nil {
+		d.undo(w, name, err)
+		return err
+	}
+
+	if g.protected(id) {
+		fmt.Fprintf(w, "Removing scale in protection from instance %s of node %s\n", id, name)
+
+		if err := setInstanceProtection(d.ctx, svc, g.Name, []string{id}, false); err != nil {
+			err = errors.Wrap(err, "failed to remove scale in protection")
+			d.undo(w, name, err)
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "Terminating instance %s of node %s\n", id, name)
+
+	_, err := svc.TerminateInstanceInAutoScalingGroupWithContext(d.ctx, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String(id),
+		ShouldDecrementDesiredCapacity: aws.Bool(true),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "failed to terminate instance")
+		d.undo(w, name, err)
+		return err
+	}
+
+	return nil
+}
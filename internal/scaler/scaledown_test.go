@@ -0,0 +1,151 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFailedRemovals(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		failed = failedRemovals{
+			"recent": now.Add(-5 * time.Minute),
+			"old":    now.Add(-failedRemovalBackoff),
+		}
+	)
+
+	if !failed.recent("recent", now) {
+		t.Errorf("expected the recent failure to be recent")
+	}
+
+	if failed.recent("old", now) {
+		t.Errorf("expected the old failure to no longer be recent")
+	}
+
+	if failed.recent("unknown", now) {
+		t.Errorf("expected a node which never failed to not be recent")
+	}
+
+	failed.prune(now)
+
+	if _, ok := failed["old"]; ok {
+		t.Errorf("expected the old failure to be forgotten")
+	}
+
+	if _, ok := failed["recent"]; !ok {
+		t.Errorf("expected the recent failure to be remembered")
+	}
+}
+
+func TestNodeBlocker(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]string
+		blocked     bool
+	}{
+		{name: "no annotations"},
+		{name: "scale down disabled", annotations: map[string]string{AnnotationScaleDownDisabled: "true"}, blocked: true},
+		{name: "scale down not disabled", annotations: map[string]string{AnnotationScaleDownDisabled: "false"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			node := testNode("a", "1", "1Gi")
+			node.ObjectMeta.Annotations = tc.annotations
+
+			if blocker := nodeBlocker(node); (blocker != "") != tc.blocked {
+				t.Errorf("expected blocked %t, got %q", tc.blocked, blocker)
+			}
+		})
+	}
+}
+
+func TestEvictionBlocker(t *testing.T) {
+	// Helper function to build a pod which is managed by a ReplicaSet and can be changed by each test.
+	managed := func(namespace string, change func(*corev1.Pod)) corev1.Pod {
+		pod := testPod("a1", "100m", "128Mi")
+		pod.ObjectMeta.Namespace = namespace
+		pod.ObjectMeta.Labels = map[string]string{"app": "a"}
+		pod.ObjectMeta.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(&metav1.ObjectMeta{Name: "a"}, appsv1.SchemeGroupVersion.WithKind("ReplicaSet")),
+		}
+
+		if change != nil {
+			change(&pod)
+		}
+
+		return pod
+	}
+
+	// Helper function to build a PodDisruptionBudget which covers the pods.
+	budget := func(namespace string, allowed int32) policyv1beta1.PodDisruptionBudget {
+		return policyv1beta1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "a",
+			},
+			Spec: policyv1beta1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"app": "a"},
+				},
+			},
+			Status: policyv1beta1.PodDisruptionBudgetStatus{
+				PodDisruptionsAllowed: allowed,
+			},
+		}
+	}
+
+	var (
+		bare = func(pod *corev1.Pod) {
+			pod.ObjectMeta.OwnerReferences = nil
+		}
+		emptyDir = func(pod *corev1.Pod) {
+			pod.Spec.Volumes = []corev1.Volume{
+				{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			}
+		}
+		hostPath = func(pod *corev1.Pod) {
+			pod.Spec.Volumes = []corev1.Volume{
+				{Name: "logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}},
+			}
+		}
+		annotate = func(value string, change func(*corev1.Pod)) func(*corev1.Pod) {
+			return func(pod *corev1.Pod) {
+				pod.ObjectMeta.Annotations = map[string]string{AnnotationSafeToEvict: value}
+
+				if change != nil {
+					change(pod)
+				}
+			}
+		}
+	)
+
+	for _, tc := range []struct {
+		name    string
+		pod     corev1.Pod
+		pdbs    []policyv1beta1.PodDisruptionBudget
+		blocked bool
+	}{
+		{name: "managed pod", pod: managed("default", nil)},
+		{name: "bare pod", pod: managed("default", bare), blocked: true},
+		{name: "emptyDir volume", pod: managed("default", emptyDir), blocked: true},
+		{name: "hostPath volume", pod: managed("default", hostPath), blocked: true},
+		{name: "kube-system pod without a PodDisruptionBudget", pod: managed(metav1.NamespaceSystem, nil), blocked: true},
+		{name: "kube-system pod with a PodDisruptionBudget", pod: managed(metav1.NamespaceSystem, nil), pdbs: []policyv1beta1.PodDisruptionBudget{budget(metav1.NamespaceSystem, 1)}},
+		{name: "kube-system pod with a PodDisruptionBudget in another namespace", pod: managed(metav1.NamespaceSystem, nil), pdbs: []policyv1beta1.PodDisruptionBudget{budget("default", 1)}, blocked: true},
+		{name: "PodDisruptionBudget which allows no disruptions", pod: managed("default", nil), pdbs: []policyv1beta1.PodDisruptionBudget{budget("default", 0)}, blocked: true},
+		{name: "safe to evict", pod: managed("default", annotate("true", emptyDir))},
+		{name: "safe to evict a bare pod", pod: managed("default", annotate("true", bare))},
+		{name: "not safe to evict", pod: managed("default", annotate("false", nil)), blocked: true},
+		{name: "safe to evict doesn't bypass a PodDisruptionBudget", pod: managed("default", annotate("true", nil)), pdbs: []policyv1beta1.PodDisruptionBudget{budget("default", 0)}, blocked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if blocker := evictionBlocker(tc.pod, tc.pdbs); (blocker != "") != tc.blocked {
+				t.Errorf("expected blocked %t, got %q", tc.blocked, blocker)
+			}
+		})
+	}
+}
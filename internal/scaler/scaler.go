@@ -1,6 +1,7 @@
 package scaler
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"time"
@@ -10,34 +11,131 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 )
 
 // WatchParams passed to the Watch function.
 type WatchParams struct {
-	// Group name of the autoscaling group.
-	Group string
+	// Groups which are managed by the scaler.
+	Groups []GroupParams
+	// DiscoveryTags which other groups must have to be managed by the scaler, declared as "key" or "key=value".
+	DiscoveryTags []string
+	// ClusterName of the cluster, groups tagged with kubernetes.io/cluster/<name> are managed by the scaler.
+	ClusterName string
 	// DryRun to ensure scaling events are correct.
 	DryRun bool
 	// Frequency of which to check for capacity changes.
 	Frequency time.Duration
-	// DownTimeout to wait before scaling down a cluster.
-	DownTimeout float64
-	// NodeCPU declare how much CPU a node has.
+	// Cooldown between scaling events, for groups which don't declare their own.
+	Cooldown CooldownParams
+	// Stabilization of the desired capacity, for groups which don't declare their own.
+	Stabilization StabilizationParams
+	// LeaderElection between replicas, so only the leader makes changes.
+	LeaderElection LeaderElectionParams
+	// ListenAddress which the health and metrics endpoints are served on, disabled when empty.
+	ListenAddress string
+	// Predictive scaling from the demand recorded in previous days and weeks.
+	Predictive PredictiveParams
+	// Schedules which raise the minimum or cap the maximum size of groups for periods of time.
+	Schedules []ScheduleParams
+	// State declares where the scaler's state is persisted.
+	State StateParams
+	// DrainTimeout to wait for pods to be evicted from a node before it is removed.
+	DrainTimeout time.Duration
+	// ProtectInstances of nodes which can't be removed from scale in, so only the scaler can remove them.
+	ProtectInstances bool
+	// LifecycleHooks drains the nodes of instances which are waiting on a termination lifecycle hook.
+	LifecycleHooks bool
+	// LifecycleHeartbeat is how often to extend a lifecycle hook while the node is being drained.
+	LifecycleHeartbeat time.Duration
+	// NodeCPU declare how much CPU a node has, for groups which don't declare their own.
 	NodeCPU int
-	// NodeMemory declare how much memory a node has.
+	// NodeMemory declare how much memory a node has, for groups which don't declare their own.
 	NodeMemory int
+	// NodePods declare how many pods a node can run, for groups which don't declare their own.
+	NodePods int
+	// KubeReservedCPU declare how much CPU is reserved on each node for Kubernetes system daemons.
+	KubeReservedCPU int
+	// KubeReservedMemory declare how much memory is reserved on each node for Kubernetes system daemons.
+	KubeReservedMemory int
+	// SystemReservedCPU declare how much CPU is reserved on each node for OS system daemons.
+	SystemReservedCPU int
+	// SystemReservedMemory declare how much memory is reserved on each node for OS system daemons.
+	SystemReservedMemory int
+	// Strategy used to calculate the desired amount of nodes.
+	Strategy string
+	// Sources of workloads which are counted towards capacity demand.
+	Sources SourceParams
+	// Filter the workloads which are counted towards capacity demand.
+	Filter FilterParams
+	// PendingPods raises the desired capacity to fit unschedulable pods onto new nodes.
+	PendingPods bool
+	// HPAMode declares how replicas are counted for workloads managed by a HorizontalPodAutoscaler.
+	HPAMode string
+	// HPAPercentile between the minimum and maximum replicas counted when using the "percentile" HPA mode.
+	HPAPercentile int
+	// Headroom which is added on top of the demand.
+	Headroom HeadroomParams
+	// Retry of failures, and when to stop making changes.
+	Retry RetryParams
+	// ShutdownTimeout drains which are in flight are given to finish when shutting down, before they are rolled back.
+	ShutdownTimeout time.Duration
 }
 
-// Watch for capacity changes and set the AWS autoscaling group desired state.
-func Watch(w io.Writer, params WatchParams) error {
+// Watch for capacity changes and set the AWS autoscaling group desired state, until the context is cancelled.
+func Watch(ctx context.Context, w io.Writer, params WatchParams) error {
 	if params.DryRun {
 		fmt.Fprintln(w, "Running in dry run mode")
 	}
 
+	if params.Frequency <= 0 {
+		return errors.Errorf("frequency must be greater than zero: %s", params.Frequency)
+	}
+
+	if params.HPAPercentile < 0 || params.HPAPercentile > 100 {
+		return errors.Errorf("HPA percentile must be between 0 and 100: %d", params.HPAPercentile)
+	}
+
+	if _, err := labels.Parse(params.Filter.Selector); err != nil {
+		return errors.Wrap(err, "failed to parse workload selector")
+	}
+
+	if params.LifecycleHooks && params.LifecycleHeartbeat <= 0 {
+		return errors.Errorf("lifecycle heartbeat must be greater than zero: %s", params.LifecycleHeartbeat)
+	}
+
+	if params.Predictive.Enabled && params.Predictive.Step <= 0 {
+		return errors.Errorf("forecast step must be greater than zero: %s", params.Predictive.Step)
+	}
+
+	if params.ShutdownTimeout < 0 {
+		return errors.Errorf("shutdown timeout must not be negative: %s", params.ShutdownTimeout)
+	}
+
+	if params.Retry.Attempts < 1 {
+		return errors.Errorf("retry attempts must be at least 1: %d", params.Retry.Attempts)
+	}
+
+	if params.Retry.Backoff < 0 || params.Retry.MaxBackoff < params.Retry.Backoff {
+		return errors.Errorf("retry backoff (%s) must not be negative or greater than the max backoff (%s)", params.Retry.Backoff, params.Retry.MaxBackoff)
+	}
+
+	if params.LeaderElection.Enabled {
+		if err := params.LeaderElection.validate(); err != nil {
+			return err
+		}
+	}
+
+	tags := discoveryTags(params.DiscoveryTags, params.ClusterName)
+
+	if len(params.Groups) == 0 && len(tags) == 0 {
+		return errors.New("no groups or discovery tags declared")
+	}
+
 	// We use the ec2metadata service to determine the region of the ASGs.
 	meta := ec2metadata.New(session.New(), &aws.Config{})
 	region, err := meta.Region()
@@ -45,136 +143,629 @@ func Watch(w io.Writer, params WatchParams) error {
 		return errors.Wrap(err, "failed to lookup region")
 	}
 
+	limiter := time.NewTicker(params.Frequency)
+	defer limiter.Stop()
+
+	wt := &watcher{
+		params:   params,
+		svc:      autoscaling.New(session.New(&aws.Config{Region: aws.String(region)})),
+		tags:     tags,
+		known:    make(map[string]bool),
+		removals: make(failedRemovals),
+		metrics:  newMetrics(),
+		hooks:    newHookDrains(),
+	}
+
+	if params.ListenAddress != "" {
+		go func() {
+			if err := serveEndpoints(params.ListenAddress, wt.metrics); err != nil {
+				fmt.Fprintf(w, "WARNING: Failed to serve health and metrics endpoints: %s\n", err)
+			}
+		}()
+	}
+
+	// Standby replicas run every cycle to keep their view of the cluster warm, but only the leader makes changes.
+	if params.LeaderElection.Enabled {
+		// The lease is renewed until the last cycle has finished, then released when Watch returns.
+		electing, stop := context.WithCancel(context.Background())
+
+		// Each attempt to acquire or renew the lease makes up to two requests, so bounding them by half the renew
+		// deadline stops a hung request from keeping a leader which can no longer renew.
+		k8s, err := newClientWithTimeout(electing, params.LeaderElection.RenewDeadline/2)
+		if err != nil {
+			stop()
+			return err
+		}
+
+		wt.elector = &elector{
+			params: params.LeaderElection,
+			k8s:    k8s,
+		}
+
+		released := make(chan struct{})
+
+		go func() {
+			wt.elector.run(electing, w)
+			close(released)
+		}()
+
+		defer func() {
+			stop()
+			<-released
+		}()
+	}
+
+	// Drains of terminating instances which are in flight are given the shutdown timeout to finish, before the lease
+	// is released.
+	defer wt.hooks.wait()
+
+	if params.Predictive.Enabled {
+		wt.history, err = openHistory(params.Predictive.HistoryPath, params.Predictive.retention())
+		if err != nil {
+			return errors.Wrap(err, "failed to open history")
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(w, "Stopped watching because: shutting down")
+			return nil
+		case <-limiter.C:
+		}
+
+		// Failed cycles are retried on the next tick, instead of exiting.
+		if err := wt.reconcile(ctx, w); err != nil {
+			// Errors caused by shutting down or losing leadership aren't counted as failures.
+			if ctx.Err() != nil || err == errNoLongerLeader {
+				fmt.Fprintf(w, "Cycle was cancelled because: %s\n", err)
+				continue
+			}
+
+			wt.fail(w, err)
+			continue
+		}
+
+		wt.succeed(w)
+	}
+}
+
+// errNoLongerLeader is returned by a cycle which was cancelled because this replica stopped being the leader.
+var errNoLongerLeader = errors.New("no longer the leader")
+
+// watcher holds what is kept between the cycles of Watch.
+type watcher struct {
+	params  WatchParams
+	svc     *autoscaling.AutoScaling
+	tags    map[string]string
+	known   map[string]bool
+	state   *state
+	history *history
+	metrics *metrics
+	elector *elector
+	hooks   *hookDrains
+	// Nodes which recently failed to be removed.
+	removals failedRemovals
+	// Consecutive cycles which have failed.
+	failures int
+	// Holding stops changes being made after repeated failures.
+	holding bool
+	// Held cycles which have succeeded since the hold started or changes were last tried.
+	held int
+	// Probing is whether the leader's current cycle isn't held, which is required to end a hold.
+	probing bool
+}
+
+// Helper function to count a failed cycle. The scaler holds after too many failures in a row, since retrying the
+// changes which caused them is likely to fail the same way.
+func (wt *watcher) fail(w io.Writer, err error) {
+	wt.failures++
+
+	wt.metrics.update(func(m *metrics) {
+		m.Failures++
+	})
+
+	kind := "retryable"
+
+	if !retryable(err) {
+		kind = "fatal"
+	}
+
+	fmt.Fprintf(w, "ERROR: Cycle failed with a %s error (%d in a row): %s\n", kind, wt.failures, err)
+
+	if wt.params.Retry.HoldAfter == 0 {
+		return
+	}
+
+	if wt.holding {
+		wt.held = 0
+		return
+	}
+
+	if wt.failures >= wt.params.Retry.HoldAfter {
+		fmt.Fprintf(w, "Holding after %d consecutive failures, no changes will be made until a cycle which makes changes succeeds\n", wt.failures)
+
+		wt.holding = true
+		wt.held = 0
+	}
+}
+
+// Helper function to reset the failures after a successful cycle.
+// A hold only ends once a cycle which made changes has succeeded, a held cycle skips the changes which were failing.
+func (wt *watcher) succeed(w io.Writer) {
+	wt.metrics.update(func(m *metrics) {
+		m.Cycles++
+	})
+
+	if wt.holding && !wt.probing {
+		wt.held++
+		return
+	}
+
+	if wt.holding {
+		fmt.Fprintln(w, "No longer holding, the cycle made changes and succeeded")
+	}
+
+	wt.holding = false
+	wt.failures = 0
+}
+
+// Helper function to determine if the next cycle is held. Every so often a held cycle tries to make changes again,
+// so the hold can end once the failures have been resolved.
+func (wt *watcher) holds() bool {
+	return wt.holding && wt.held < wt.params.Retry.HoldAfter
+}
+
+// Helper function to run a single cycle: calculate the desired capacity of each group and apply it.
+// The leader's cycle is cancelled when it stops being the leader, so it stops making changes.
+func (wt *watcher) reconcile(parent context.Context, w io.Writer) (err error) {
 	var (
-		svc       = autoscaling.New(session.New(&aws.Config{Region: aws.String(region)}))
-		limiter   = time.Tick(params.Frequency)
-		prevScale = time.Now()
+		ctx    = parent
+		params = wt.params
+		svc    = wt.svc
+		leader = wt.elector == nil || wt.elector.isLeader()
+		hold   = wt.holds()
+		dry    = params.DryRun || !leader || hold
 	)
 
-	for {
-		<-limiter
+	wt.probing = leader && !hold
+
+	if wt.elector != nil && leader {
+		var cancel context.CancelFunc
+
+		ctx, cancel = wt.elector.leading(parent)
+		defer cancel()
+
+		defer func() {
+			if err != nil && ctx.Err() != nil && parent.Err() == nil {
+				err = errNoLongerLeader
+			}
+		}()
+	}
+
+	wt.metrics.update(func(m *metrics) {
+		m.Leader = leader
+		m.Hold = wt.holding
+	})
+
+	if !leader {
+		fmt.Fprintln(w, "Not the leader, calculating capacity without making changes")
+	}
+
+	if hold {
+		fmt.Fprintf(w, "Holding after %d consecutive failures, calculating capacity without making changes\n", wt.failures)
+	}
 
-		// Creates the in-cluster config.
-		config, err := rest.InClusterConfig()
+	if wt.holding && !hold && leader {
+		fmt.Fprintf(w, "Holding after %d consecutive failures, trying to make changes to check if the failures have been resolved\n", wt.failures)
+	}
+
+	k8s, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Drains and saving the state are given the shutdown timeout to finish, so they aren't left half done.
+	d, err := wt.newDrainer(parent)
+	if err != nil {
+		return err
+	}
+
+	defer d.close()
+
+	// The state is loaded once, then kept in memory and saved whenever it changes.
+	// Standby replicas reload it every cycle, so it is current when they become the leader.
+	if wt.state == nil || !leader {
+		err = retry(ctx, w, params.Retry, "loading state", func() (err error) {
+			wt.state, err = loadState(k8s, params.State)
+			return err
+		})
 		if err != nil {
-			return errors.Wrap(err, "failed to get k8s incluster config")
+			return errors.Wrap(err, "failed to load state")
 		}
+	}
 
-		// Creates the clientset for querying APIs.
-		k8s, err := kubernetes.NewForConfig(config)
-		if err != nil {
-			return errors.Wrap(err, "failed to get k8s client")
+	st := wt.state
+
+	fmt.Fprintln(w, "Looking up Autoscaling Groups")
+
+	var groups []*group
+
+	err = retry(ctx, w, params.Retry, "looking up Autoscaling Groups", func() (err error) {
+		groups, err = discoverGroups(ctx, w, svc, params, wt.tags)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to get AWS autoscaling groups")
+	}
+
+	managed := make(map[string]bool)
+
+	for _, g := range groups {
+		managed[g.Name] = true
+
+		if !wt.known[g.Name] {
+			fmt.Fprintf(w, "Managing Autoscaling Group: %s\n", g.Name)
+			wt.known[g.Name] = true
+		}
+
+		// Groups which have never been managed start cooling down from now.
+		if _, ok := st.Cooldowns[g.Name]; !ok {
+			st.Cooldowns[g.Name] = cooldown{
+				LastScaleDown: time.Now(),
+			}
+		}
+	}
+
+	for name := range wt.known {
+		if !managed[name] {
+			fmt.Fprintf(w, "No longer managing Autoscaling Group: %s\n", name)
+			delete(wt.known, name)
+
+			wt.metrics.update(func(m *metrics) {
+				delete(m.Desired, name)
+				delete(m.Current, name)
+			})
+		}
+	}
+
+	st.prune(managed)
+
+	fmt.Fprintln(w, "Looking up Nodes")
+
+	var nodes *corev1.NodeList
+
+	err = retry(ctx, w, params.Retry, "listing nodes", func() (err error) {
+		nodes, err = k8s.CoreV1().Nodes().List(metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to list nodes")
+	}
+
+	// Failures which only affect part of the cycle are reported and counted once the cycle has finished.
+	var failures []error
+
+	if params.LifecycleHooks && !dry {
+		fmt.Fprintln(w, "Looking up instances waiting on lifecycle hooks")
+
+		if err := handleLifecycleHooks(ctx, w, svc, wt.hooks, func() (*drainer, error) { return wt.newDrainer(parent) }, groups, nodes.Items, params); err != nil {
+			fmt.Fprintln(w, err)
+			failures = append(failures, err)
 		}
+	}
 
-		fmt.Println("Looking up Autoscaling Group")
+	var daemonsets []appsv1.DaemonSet
 
-		asg, err := getScalingGroup(svc, params.Group, region)
+	if params.Sources.DaemonSets {
+		err = retry(ctx, w, params.Retry, "listing DaemonSets", func() (err error) {
+			daemonsets, err = listDaemonSets(k8s)
+			return err
+		})
 		if err != nil {
-			return errors.Wrap(err, "failed to get AWS autoscaling group")
+			return errors.Wrap(err, "failed to list DaemonSets")
 		}
+	}
+
+	// Groups whose node capacity can't be calculated are still assigned workloads, so those workloads aren't
+	// counted towards another group, but they aren't scaled.
+	var usable []*group
+
+	for _, g := range groups {
+		fmt.Fprintf(w, "Calculating node capacity for group: %s\n", g.Name)
+
+		g.Nodes = groupNodes(nodes.Items, g.ASG)
+		g.Templates = getGroupTemplates(g.ASG, g.Nodes)
+
+		// The kubelet already excludes its reservations from the allocatable amount of registered nodes,
+		// so they are only deducted from the declared capacity.
+		declared := resources{
+			CPU:    g.Config.NodeCPU - params.KubeReservedCPU - params.SystemReservedCPU,
+			Memory: g.Config.NodeMemory - params.KubeReservedMemory - params.SystemReservedMemory,
+			Pods:   g.Config.NodePods,
+		}
+
+		node := getNodeCapacity(w, g.Nodes, declared)
 
-		fmt.Println("Calculating Deployments requests")
+		var overhead resources
 
-		cpu, mem, err := getDeploymentRequests(k8s)
+		if params.Sources.DaemonSets {
+			overhead = getDaemonSetOverhead(w, daemonsets, g.Templates)
+		}
+
+		g.Node = node.sub(overhead)
+
+		if g.Node.CPU <= 0 || g.Node.Memory <= 0 || g.Node.Pods <= 0 {
+			fmt.Fprintf(w, "WARNING: Not scaling group %s because: node capacity CPU %d / Memory %d / Pods %d is exhausted by the overhead on each node\n", g.Name, g.Node.CPU, g.Node.Memory, g.Node.Pods)
+			continue
+		}
+
+		fmt.Fprintf(w, "Each node has the following amount available for workloads CPU %d / Memory %d / Pods %d\n", g.Node.CPU, g.Node.Memory, g.Node.Pods)
+
+		usable = append(usable, g)
+	}
+
+	fmt.Fprintln(w, "Calculating workload requests")
+
+	var workloads []workload
+
+	err = retry(ctx, w, params.Retry, "calculating workload requests", func() (err error) {
+		workloads, err = getDeploymentRequests(w, k8s, params)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to calculate total workload requests")
+	}
+
+	assignWorkloads(w, groups, workloads)
+
+	// Pending pods are already counted by their workloads, so they set a floor on top of the current nodes
+	// instead of being added to the demand. This catches pods which don't fit because the cluster is fragmented.
+	if params.PendingPods {
+		fmt.Fprintln(w, "Calculating unschedulable pod requests")
+
+		var pending []corev1.Pod
+
+		err = retry(ctx, w, params.Retry, "calculating unschedulable pod requests", func() (err error) {
+			pending, err = getUnschedulablePods(w, k8s, params.Filter)
+			return err
+		})
 		if err != nil {
-			return errors.Wrap(err, "failed to calculate total Deployment requests")
+			return errors.Wrap(err, "failed to calculate unschedulable pod requests")
+		}
+
+		assignPending(w, groups, pending)
+	}
+
+	for _, g := range usable {
+		name := g.Name
+
+		total := sumResources(g.Pods)
+
+		fmt.Fprintf(w, "Group %s requires the following amount to run CPU %d / Memory %d / Pods %d\n", name, total.CPU, total.Memory, total.Pods)
+
+		demand := getDesired(w, params.Strategy, g.Pods, g.Node)
+
+		p := plan{
+			Demand:   demand,
+			Headroom: getHeadroom(demand, g.Node, g.Config.Headroom),
 		}
 
-		fmt.Printf("Kubernetes Deployments require the following amount to run CPU %d / Memory %d\n", cpu, mem)
+		desired := p.Total()
 
-		desired := getDesired(cpu, mem, params.NodeCPU, params.NodeMemory)
+		fmt.Fprintf(w, "The desired amount is: %d (demand %d / headroom %d)\n", desired, p.Demand, p.Headroom)
 
-		fmt.Printf("The desired amount is: %d\n", desired)
+		if len(g.Pending) > 0 {
+			floor, nodes := pendingFloor(g.Pending, g.Node, *g.ASG.DesiredCapacity, len(g.Nodes))
 
-		if desired < *asg.MinSize {
-			fmt.Printf("The desired capacity (%d) is less than the ASG minimum constraint (%d)\n", desired, *asg.MinSize)
-			desired = *asg.MinSize
+			if floor > desired {
+				fmt.Fprintf(w, "The desired capacity (%d) is raised to %d by %d unschedulable pods which need %d more nodes\n", desired, floor, len(g.Pending), nodes)
+				desired = floor
+			}
 		}
 
-		if desired > *asg.MaxSize {
-			fmt.Printf("The desired capacity (%d) is more than the ASG maximum constraint (%d)\n", desired, *asg.MaxSize)
-			desired = *asg.MaxSize
+		if params.Predictive.Enabled {
+			err := wt.history.record(sample{
+				Group:  name,
+				Time:   time.Now(),
+				CPU:    total.CPU,
+				Memory: total.Memory,
+				Pods:   total.Pods,
+			})
+			if err != nil {
+				fmt.Fprintf(w, "WARNING: Failed to record demand history: %s\n", err)
+			}
+
+			if forecast, ok := params.Predictive.forecast(wt.history, name, time.Now()); ok {
+				fmt.Fprintf(w, "The forecast demand for the next %s is CPU %d / Memory %d / Pods %d\n", params.Predictive.Horizon, forecast.CPU, forecast.Memory, forecast.Pods)
+
+				if nodes := forecastNodes(forecast, g.Node); nodes > desired {
+					fmt.Fprintf(w, "The desired capacity (%d) is raised to %d by the forecast demand\n", desired, nodes)
+					desired = nodes
+				}
+			}
 		}
 
-		if desired == *asg.DesiredCapacity {
-			fmt.Printf("The desired capacity (%d) has not changed\n", *asg.DesiredCapacity)
+		if params.ProtectInstances {
+			candidates, err := g.candidates(k8s)
+			if err == nil {
+				err = protectInstances(ctx, w, svc, g, candidates, dry)
+			}
+
+			if err != nil {
+				fmt.Fprintln(w, err)
+				failures = append(failures, err)
+			}
+		}
+
+		var (
+			current = *g.ASG.DesiredCapacity
+			now     = time.Now()
+		)
+
+		history := append(st.Recommendations[name], recommendation{
+			Time:    now,
+			Desired: desired,
+		})
+
+		st.Recommendations[name] = trimRecommendations(history, g.Config.Stabilization, now)
+
+		if stabilized := stabilize(st.Recommendations[name], g.Config.Stabilization, current, now); stabilized != desired {
+			fmt.Fprintf(w, "The desired capacity (%d) is stabilized to %d because: of the capacity desired within the stabilization window\n", desired, stabilized)
+			desired = stabilized
+		}
+
+		st.ScaleUps[name] = trimScaleUps(st.ScaleUps[name], g.Config.Stabilization, now)
+
+		if limited := limitScaleUp(st.ScaleUps[name], g.Config.Stabilization, current, desired); limited != desired {
+			fmt.Fprintf(w, "The desired capacity (%d) is limited to %d because: of the nodes which can be added within %s\n", desired, limited, g.Config.Stabilization.UpPeriod)
+			desired = limited
+		}
+
+		min, max := g.bounds()
+
+		if desired < min {
+			fmt.Fprintf(w, "The desired capacity (%d) is less than the minimum constraint (%d)\n", desired, min)
+			desired = min
+		}
+
+		if desired > max {
+			fmt.Fprintf(w, "The desired capacity (%d) is more than the maximum constraint (%d)\n", desired, max)
+			desired = max
+		}
+
+		desired = applySchedules(w, params.Schedules, name, desired, min, max, now)
+
+		wt.metrics.update(func(m *metrics) {
+			m.Desired[name] = desired
+			m.Current[name] = current
+		})
+
+		if desired == current {
+			fmt.Fprintf(w, "The desired capacity (%d) has not changed\n", current)
 			continue
 		}
 
-		// Check if this is a "down scale" event and if we have had one of these in the past X minutes.
-		if desired < *asg.DesiredCapacity && time.Now().Sub(prevScale).Minutes() < params.DownTimeout {
-			fmt.Println("Skipping this scale down event because: Cooling down")
+		direction := "down"
+
+		if desired > current {
+			direction = "up"
+		}
+
+		// Check if we have scaled recently enough that this event has to wait.
+		if wait := st.Cooldowns[name].remaining(g.Config.Cooldown, desired > current, now); wait > 0 {
+			fmt.Fprintf(w, "Skipping this scale %s event because: Cooling down for another %s\n", direction, wait.Round(time.Second))
+			continue
+		}
+
+		// Nodes are drained before being removed, instead of letting the group pick an instance to terminate.
+		if desired < current {
+			fmt.Fprintf(w, "Removing %d nodes to scale from %d to %d\n", current-desired, current, desired)
+
+			removed, err := removeNodes(ctx, w, svc, k8s, d, g, wt.removals, current-desired, dry)
+			if err != nil {
+				fmt.Fprintln(w, err)
+				failures = append(failures, err)
+			}
+
+			if removed > 0 && !dry {
+				c := st.Cooldowns[name]
+				c.LastScaleDown = time.Now()
+				st.Cooldowns[name] = c
+			}
+
 			continue
 		}
 
-		fmt.Printf("Setting the desired capacity from %d to %d\n", *asg.DesiredCapacity, desired)
+		fmt.Fprintf(w, "Setting the desired capacity from %d to %d\n", current, desired)
 
 		// Don't make any changes. Perfect for debugging.
-		if params.DryRun {
+		if dry {
 			continue
 		}
 
-		_, err = svc.SetDesiredCapacity(&autoscaling.SetDesiredCapacityInput{
-			AutoScalingGroupName: aws.String(params.Group),
-			DesiredCapacity:      aws.Int64(desired),
+		err = retry(ctx, w, params.Retry, "setting the desired capacity", func() error {
+			_, err := svc.SetDesiredCapacityWithContext(ctx, &autoscaling.SetDesiredCapacityInput{
+				AutoScalingGroupName: aws.String(name),
+				DesiredCapacity:      aws.Int64(desired),
+			})
+			return err
 		})
 		if err != nil {
-			fmt.Println(err)
+			err = errors.Wrapf(err, "failed to set the desired capacity of group %s", name)
+			fmt.Fprintln(w, err)
+			failures = append(failures, err)
+			continue
 		}
 
-		prevScale = time.Now()
+		c := st.Cooldowns[name]
+		c.LastScaleUp = time.Now()
+		st.Cooldowns[name] = c
+
+		st.ScaleUps[name] = append(st.ScaleUps[name], scaleUp{
+			Time: now,
+			From: current,
+			To:   desired,
+		})
+	}
+
+	// Don't make any changes. Perfect for debugging.
+	if !dry {
+		saveState(w, d.k8s, st, params.State)
 	}
+
+	if len(failures) > 0 {
+		return errors.Wrapf(failures[0], "%d operations failed, the first", len(failures))
+	}
+
+	return nil
 }
 
-func getScalingGroup(svc *autoscaling.AutoScaling, name, region string) (*autoscaling.Group, error) {
-	// Query the ASGs based on the names provided.
-	asgs, err := svc.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
-		AutoScalingGroupNames: []*string{
-			aws.String(name),
-		},
-		MaxRecords: aws.Int64(int64(1)),
-	})
+// Helper function to persist the state, which is reported instead of failing the cycle since the changes have already been made.
+func saveState(w io.Writer, k8s *kubernetes.Clientset, st *state, params StateParams) {
+	if err := st.save(k8s, params); err != nil {
+		fmt.Fprintf(w, "WARNING: Failed to save state to ConfigMap %s/%s: %s\n", params.Namespace, params.Name, err)
+	}
+}
+
+// Helper function which returns the workloads to be run, with their replicas adjusted for HorizontalPodAutoscalers.
+func getDeploymentRequests(w io.Writer, k8s *kubernetes.Clientset, params WatchParams) ([]workload, error) {
+	workloads, err := listWorkloads(k8s, params.Sources, params.Filter)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(asgs.AutoScalingGroups) != 1 {
-		return nil, errors.New("Failed to lookup the ASG")
+	bounds, err := getHPABounds(k8s)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list HorizontalPodAutoscalers")
 	}
 
-	return asgs.AutoScalingGroups[0], nil
-}
+	applyHPABounds(w, workloads, bounds, params.HPAMode, params.HPAPercentile)
 
-// Helper function which calculates how much CPU + Memory is required to run all the deployments on the cluster.
-func getDeploymentRequests(k8s *kubernetes.Clientset) (int, int, error) {
-	var (
-		cpu int
-		mem int
-	)
+	return workloads, nil
+}
 
-	deployments, err := k8s.Extensions().Deployments(corev1.NamespaceAll).List(metav1.ListOptions{})
-	if err != nil {
-		return cpu, mem, err
+// Helper function to determine desired instances for the autoscaling group.
+func getDesired(w io.Writer, strategy string, pods []resources, node resources) int64 {
+	if strategy == StrategyAggregate {
+		total := sumResources(pods)
+		return getDesiredAggregate(total.CPU, total.Memory, node.CPU, node.Memory)
 	}
 
-	for _, deployment := range deployments.Items {
-		for _, container := range deployment.Spec.Template.Spec.Containers {
-			reqCPU := container.Resources.Requests[corev1.ResourceCPU]
-			reqMem := container.Resources.Requests[corev1.ResourceMemory]
+	desired, unplaceable := packNodes(pods, node)
 
-			cpu = cpu + int(reqCPU.MilliValue())*int(*deployment.Spec.Replicas)
-			mem = mem + int(reqMem.Value()/1024.0/1024.0)*int(*deployment.Spec.Replicas)
-		}
+	if len(unplaceable) > 0 {
+		fmt.Fprintf(w, "Skipped %d pods which are larger than a node\n", len(unplaceable))
 	}
 
-	return cpu, mem, nil
+	return int64(desired)
 }
 
-// Helper function to determine desired instances for the autoscaling group.
-func getDesired(requestsCPU, requestsMem, nodeCPU, nodeMemory int) int64 {
+// Helper function to determine desired instances by dividing the total requests by the size of a node.
+func getDesiredAggregate(requestsCPU, requestsMem, nodeCPU, nodeMemory int) int64 {
 	var (
-		desiredByCPU = requestsCPU / nodeCPU
-		desiredByMem = requestsMem / nodeMemory
+		desiredByCPU = divideRoundUp(requestsCPU, nodeCPU)
+		desiredByMem = divideRoundUp(requestsMem, nodeMemory)
 	)
 
 	// We default to "desired by CPU" as our default.
@@ -185,10 +776,11 @@ func getDesired(requestsCPU, requestsMem, nodeCPU, nodeMemory int) int64 {
 		desired = desiredByMem
 	}
 
-	// We increase the "desired" by one because our division chops off a
-	// certain percentage.
-	//   eg. 2.45 = 2 (but we still need compute for the .45)
-	desired++
-
 	return int64(desired)
 }
+
+// Helper function to divide and round up to the nearest whole number.
+// eg. 2.45 = 3 (we still need compute for the .45)
+func divideRoundUp(a, b int) int {
+	return (a + b - 1) / b
+}
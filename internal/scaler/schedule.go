@@ -0,0 +1,161 @@
+package scaler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ScheduleParams declares a minimum or maximum size which applies to groups for a period of time.
+type ScheduleParams struct {
+	// Name of the schedule, used when reporting which schedule applied.
+	Name string
+	// Group which the schedule applies to, all groups when empty.
+	Group string
+	// Cron expression which declares when the schedule starts.
+	Cron string
+	// Duration of the schedule after it starts.
+	Duration time.Duration
+	// Location which the cron expression is evaluated in.
+	Location *time.Location
+	// MinSize the group is raised to while the schedule applies.
+	MinSize *int64
+	// MaxSize the group is capped at while the schedule applies.
+	MaxSize *int64
+
+	expr cronExpression
+}
+
+// ParseSchedule parses a schedule declared as a name followed by semicolon separated options.
+// eg. business-hours;cron=30 7 * * Mon-Fri;duration=10h30m;timezone=Australia/Sydney;min=8
+func ParseSchedule(spec string) (ScheduleParams, error) {
+	var (
+		parts    = strings.Split(spec, ";")
+		schedule = ScheduleParams{
+			Name:     strings.TrimSpace(parts[0]),
+			Location: time.UTC,
+		}
+	)
+
+	if schedule.Name == "" {
+		return schedule, errors.Errorf("schedule name not declared: %s", spec)
+	}
+
+	for _, part := range parts[1:] {
+		option := strings.SplitN(part, "=", 2)
+		if len(option) != 2 {
+			return schedule, errors.Errorf("schedule option must be declared as key=value: %s", part)
+		}
+
+		var (
+			key   = strings.TrimSpace(option[0])
+			value = strings.TrimSpace(option[1])
+			err   error
+		)
+
+		switch key {
+		case "group":
+			schedule.Group = value
+		case "cron":
+			schedule.Cron = value
+			schedule.expr, err = parseCron(value)
+		case "duration":
+			schedule.Duration, err = parseDuration(value)
+		case "timezone":
+			schedule.Location, err = time.LoadLocation(value)
+		case "min":
+			schedule.MinSize, err = parseSize(value)
+		case "max":
+			schedule.MaxSize, err = parseSize(value)
+		default:
+			err = errors.Errorf("unknown option: %s", key)
+		}
+
+		if err != nil {
+			return schedule, errors.Wrapf(err, "failed to parse schedule %s: invalid %s", schedule.Name, key)
+		}
+	}
+
+	if schedule.Cron == "" {
+		return schedule, errors.Errorf("schedule %s does not declare a cron expression", schedule.Name)
+	}
+
+	if schedule.Duration == 0 {
+		return schedule, errors.Errorf("schedule %s does not declare a duration", schedule.Name)
+	}
+
+	if schedule.MinSize == nil && schedule.MaxSize == nil {
+		return schedule, errors.Errorf("schedule %s does not declare a min or max", schedule.Name)
+	}
+
+	return schedule, nil
+}
+
+// Helper function to determine if the schedule applies at a time, which is when it started within its duration.
+func (s ScheduleParams) active(now time.Time) bool {
+	start := s.expr.next(now.Add(-s.Duration).In(s.Location))
+
+	return !start.IsZero() && !start.After(now)
+}
+
+// Helper function to determine if the schedule applies to a group.
+func (s ScheduleParams) appliesTo(group string) bool {
+	return s.Group == "" || s.Group == group
+}
+
+// Helper function to apply the active schedules to the desired capacity of a group.
+// The highest minimum and the lowest maximum win, and a maximum wins over a minimum when they conflict.
+// A minimum is never raised above the group's maximum size and a maximum is never lowered below the group's
+// minimum size, since the group can't be scaled beyond them.
+func applySchedules(w io.Writer, schedules []ScheduleParams, group string, desired, min, max int64, now time.Time) int64 {
+	var floor, ceiling *ScheduleParams
+
+	for i := range schedules {
+		s := &schedules[i]
+
+		if !s.appliesTo(group) || !s.active(now) {
+			continue
+		}
+
+		if s.MinSize != nil && (floor == nil || *s.MinSize > *floor.MinSize) {
+			floor = s
+		}
+
+		if s.MaxSize != nil && (ceiling == nil || *s.MaxSize < *ceiling.MaxSize) {
+			ceiling = s
+		}
+	}
+
+	if floor != nil && desired < *floor.MinSize {
+		raised := *floor.MinSize
+
+		if raised > max {
+			fmt.Fprintf(w, "The minimum (%d) of schedule %s is more than the maximum constraint (%d)\n", raised, floor.Name, max)
+			raised = max
+		}
+
+		if desired < raised {
+			fmt.Fprintf(w, "The desired capacity (%d) is raised to %d by schedule: %s\n", desired, raised, floor.Name)
+			desired = raised
+		}
+	}
+
+	if ceiling != nil && desired > *ceiling.MaxSize {
+		capped := *ceiling.MaxSize
+
+		if capped < min {
+			fmt.Fprintf(w, "The maximum (%d) of schedule %s is less than the minimum constraint (%d)\n", capped, ceiling.Name, min)
+			capped = min
+		}
+
+		if desired > capped {
+			fmt.Fprintf(w, "The desired capacity (%d) is capped at %d by schedule: %s\n", desired, capped, ceiling.Name)
+			desired = capped
+		}
+	}
+
+	return desired
+}
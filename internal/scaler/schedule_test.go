@@ -0,0 +1,118 @@
+package scaler
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// Helper function to parse a schedule, failing the test when it is invalid.
+func mustParseSchedule(t *testing.T, spec string) ScheduleParams {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		t.Fatalf("failed to parse schedule %s: %s", spec, err)
+	}
+
+	return schedule
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		spec string
+		err  bool
+	}{
+		{spec: "business-hours;cron=30 7 * * Mon-Fri;duration=10h30m;timezone=Australia/Sydney;min=8"},
+		{spec: "overnight;cron=0 22 * * *;duration=8h;max=3;group=nodes"},
+		{spec: ";cron=0 22 * * *;duration=8h;max=3", err: true},
+		{spec: "overnight;duration=8h;max=3", err: true},
+		{spec: "overnight;cron=0 22 * * *;max=3", err: true},
+		{spec: "overnight;cron=0 22 * * *;duration=8h", err: true},
+		{spec: "overnight;cron=0 22 * *;duration=8h;max=3", err: true},
+		{spec: "overnight;cron=0 22 * * *;duration=8h;max=-1", err: true},
+		{spec: "overnight;cron=0 22 * * *;duration=8h;timezone=Mars/Olympus_Mons;max=3", err: true},
+		{spec: "overnight;cron=0 22 * * *;duration=8h;size=3", err: true},
+		{spec: "overnight;cron", err: true},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			_, err := ParseSchedule(tc.spec)
+
+			if tc.err && err == nil {
+				t.Errorf("expected an error")
+			}
+
+			if !tc.err && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestScheduleActive(t *testing.T) {
+	var (
+		sydney   = loadLocation(t, "Australia/Sydney")
+		business = mustParseSchedule(t, "business-hours;cron=30 7 * * Mon-Fri;duration=10h30m;timezone=Australia/Sydney;min=8")
+		// Overnight runs past midnight, so it is still active the morning after it started.
+		overnight = mustParseSchedule(t, "overnight;cron=0 22 * * *;duration=8h;timezone=Australia/Sydney;max=3")
+	)
+
+	for _, tc := range []struct {
+		name     string
+		schedule ScheduleParams
+		now      time.Time
+		active   bool
+	}{
+		{"before it starts", business, time.Date(2026, 10, 1, 7, 29, 0, 0, sydney), false},
+		{"when it starts", business, time.Date(2026, 10, 1, 7, 30, 0, 0, sydney), true},
+		{"while it applies", business, time.Date(2026, 10, 1, 12, 0, 0, 0, sydney), true},
+		{"before it ends", business, time.Date(2026, 10, 1, 17, 59, 0, 0, sydney), true},
+		{"when it ends", business, time.Date(2026, 10, 1, 18, 0, 0, 0, sydney), false},
+		{"on a day it doesn't start", business, time.Date(2026, 10, 3, 12, 0, 0, 0, sydney), false},
+		{"evaluated in its time zone", business, time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC), true},
+		{"across midnight", overnight, time.Date(2026, 10, 2, 5, 0, 0, 0, sydney), true},
+		{"after midnight once it ended", overnight, time.Date(2026, 10, 2, 6, 30, 0, 0, sydney), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if active := tc.schedule.active(tc.now); active != tc.active {
+				t.Errorf("expected active %t, got %t", tc.active, active)
+			}
+		})
+	}
+}
+
+func TestApplySchedules(t *testing.T) {
+	var (
+		now   = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		raise = mustParseSchedule(t, "raise;cron=0 9 * * *;duration=8h;min=8")
+		// Higher only applies to the web group.
+		higher = mustParseSchedule(t, "higher;cron=0 9 * * *;duration=8h;min=10;group=web")
+		capped = mustParseSchedule(t, "cap;cron=0 9 * * *;duration=8h;max=3")
+		// Inactive started too long ago to apply.
+		inactive = mustParseSchedule(t, "inactive;cron=0 1 * * *;duration=1h;min=20")
+	)
+
+	for _, tc := range []struct {
+		name      string
+		schedules []ScheduleParams
+		group     string
+		desired   int64
+		min, max  int64
+		expected  int64
+	}{
+		{"no schedules", nil, "nodes", 5, 0, 20, 5},
+		{"raised to the minimum", []ScheduleParams{raise}, "nodes", 5, 0, 20, 8},
+		{"above the minimum", []ScheduleParams{raise}, "nodes", 12, 0, 20, 12},
+		{"minimum limited by the group maximum", []ScheduleParams{raise}, "nodes", 5, 0, 6, 6},
+		{"highest minimum wins", []ScheduleParams{raise, higher}, "web", 5, 0, 20, 10},
+		{"schedule for another group", []ScheduleParams{higher}, "nodes", 5, 0, 20, 5},
+		{"capped at the maximum", []ScheduleParams{capped}, "nodes", 5, 0, 20, 3},
+		{"maximum limited by the group minimum", []ScheduleParams{capped}, "nodes", 5, 4, 20, 4},
+		{"maximum wins over a minimum", []ScheduleParams{raise, capped}, "nodes", 5, 0, 20, 3},
+		{"inactive schedule", []ScheduleParams{inactive}, "nodes", 5, 0, 20, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if desired := applySchedules(ioutil.Discard, tc.schedules, tc.group, tc.desired, tc.min, tc.max, now); desired != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, desired)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package scaler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// contextTransport attaches a context to each request, so requests are cancelled along with the context.
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+// RoundTrip sends the request with the transport's context.
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req.WithContext(t.ctx))
+}
+
+// Helper function to create a Kubernetes client whose requests are cancelled along with the context.
+func newClient(ctx context.Context) (*kubernetes.Clientset, error) {
+	return newClientWithTimeout(ctx, 0)
+}
+
+// Helper function to create a Kubernetes client whose requests are cancelled along with the context, or once they
+// have taken longer than the timeout. Requests aren't timed out when it is zero.
+func newClientWithTimeout(ctx context.Context, timeout time.Duration) (*kubernetes.Clientset, error) {
+	// Creates the in-cluster config.
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get k8s incluster config")
+	}
+
+	config.Timeout = timeout
+
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		return contextTransport{
+			ctx:  ctx,
+			next: rt,
+		}
+	}
+
+	// Creates the clientset for querying APIs.
+	k8s, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get k8s client")
+	}
+
+	return k8s, nil
+}
+
+// Helper function to create a context which is cancelled a grace period after the parent is cancelled,
+// giving work which is in flight a deadline to finish.
+func withGrace(parent context.Context, grace time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		select {
+		case <-parent.Done():
+		case <-ctx.Done():
+			return
+		}
+
+		select {
+		case <-time.After(grace):
+		case <-ctx.Done():
+		}
+
+		cancel()
+	}()
+
+	return ctx, cancel
+}
+
+// drainer cordons and drains nodes. Drains which are in flight when the scaler shuts down are given the shutdown
+// timeout to finish, otherwise they are rolled back.
+type drainer struct {
+	// Context and client which are cancelled the shutdown timeout after shutting down.
+	ctx    context.Context
+	cancel context.CancelFunc
+	k8s    *kubernetes.Clientset
+	// Client which is never cancelled, so a drain can still be rolled back after the shutdown timeout.
+	rollback *kubernetes.Clientset
+	timeout  time.Duration
+}
+
+// Helper function to create a drainer for a cycle, which must be closed once the cycle has finished.
+func newDrainer(ctx context.Context, params WatchParams) (*drainer, error) {
+	grace, cancel := withGrace(ctx, params.ShutdownTimeout)
+
+	k8s, err := newClient(grace)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	rollback, err := newClient(context.Background())
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &drainer{
+		ctx:      grace,
+		cancel:   cancel,
+		k8s:      k8s,
+		rollback: rollback,
+		timeout:  params.DrainTimeout,
+	}, nil
+}
+
+// Helper function to create a drainer whose drains are given the shutdown timeout to finish when shutting down,
+// but are rolled back straight away once another replica could take over as the leader.
+func (wt *watcher) newDrainer(ctx context.Context) (*drainer, error) {
+	d, err := newDrainer(ctx, wt.params)
+	if err != nil {
+		return nil, err
+	}
+
+	if wt.elector != nil {
+		leading, cancel := wt.elector.leading(d.ctx)
+
+		go func() {
+			<-leading.Done()
+			cancel()
+
+			if ctx.Err() == nil {
+				d.close()
+			}
+		}()
+	}
+
+	return d, nil
+}
+
+// Helper function to release the drainer's context.
+func (d *drainer) close() {
+	d.cancel()
+}
+
+// Helper function to cordon and drain a node.
+func (d *drainer) drain(w io.Writer, name string) error {
+	fmt.Fprintf(w, "Cordoning node %s\n", name)
+
+	if err := cordonNode(d.k8s, name); err != nil {
+		return errors.Wrap(err, "failed to cordon node")
+	}
+
+	fmt.Fprintf(w, "Draining node %s\n", name)
+
+	if err := drainNode(w, d.k8s, name, d.timeout); err != nil {
+		return errors.Wrap(err, "failed to drain node")
+	}
+
+	return nil
+}
+
+// Helper function to uncordon a node which could not be drained, so its pods can be scheduled onto it again.
+func (d *drainer) undo(w io.Writer, name string, reason error) {
+	fmt.Fprintf(w, "Uncordoning node %s because: %s\n", name, reason)
+
+	if err := uncordonNode(d.rollback, name); err != nil {
+		fmt.Fprintf(w, "WARNING: Failed to uncordon node %s: %s\n", name, err)
+	}
+}
+
+// Helper function to determine if the shutdown timeout has passed, so in-flight work is being cancelled.
+func (d *drainer) cancelled() bool {
+	return d.ctx.Err() != nil
+}
@@ -0,0 +1,105 @@
+package scaler
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// simulation tracks the nodes which remain in a group while nodes are removed from it.
+type simulation struct {
+	nodes []simulatedNode
+}
+
+// simulatedNode is a node which pods can be rescheduled onto.
+type simulatedNode struct {
+	Name     string
+	Template nodeTemplate
+	// Free resources which are not requested by the pods on the node.
+	Free resources
+	// Placed pods which have been rescheduled onto the node by the simulation.
+	Placed []corev1.Pod
+}
+
+// Helper function to start a simulation from the current allocations of the group's nodes.
+// Cordoned nodes aren't candidates, so pods are never rescheduled onto them.
+func newSimulation(candidates []candidate) *simulation {
+	sim := new(simulation)
+
+	for _, c := range candidates {
+		sim.nodes = append(sim.nodes, simulatedNode{
+			Name:     c.Node.ObjectMeta.Name,
+			Template: templateFromNode(c.Node),
+			Free:     nodeAllocatable(c.Node).sub(c.Requested),
+		})
+	}
+
+	return sim
+}
+
+// Helper function to simulate removing a node by rescheduling its pods onto the remaining nodes.
+// Pods which were rescheduled onto the node earlier in the simulation have to be rescheduled again.
+// The node is only removed from the simulation when all of its pods fit, otherwise the pods which
+// don't fit are returned and the simulation is left unchanged.
+func (s *simulation) remove(c candidate) []corev1.Pod {
+	var (
+		remaining   []simulatedNode
+		pods        = make([]corev1.Pod, len(c.Pods))
+		allocatable = nodeAllocatable(c.Node)
+	)
+
+	copy(pods, c.Pods)
+
+	for _, node := range s.nodes {
+		if node.Name == c.Node.ObjectMeta.Name {
+			pods = append(pods, node.Placed...)
+			continue
+		}
+
+		// The placed pods are copied, so a failed removal doesn't change the simulation.
+		node.Placed = append([]corev1.Pod(nil), node.Placed...)
+		remaining = append(remaining, node)
+	}
+
+	// Place the biggest pods first, since they are the hardest to fit.
+	sort.SliceStable(pods, func(i, j int) bool {
+		return podResources(pods[i]).share(allocatable) > podResources(pods[j]).share(allocatable)
+	})
+
+	var blocking []corev1.Pod
+
+	for _, pod := range pods {
+		request := podResources(pod)
+		placed := false
+
+		for i := range remaining {
+			if request.fits(remaining[i].Free) && remaining[i].Template.schedulable(pod.Spec) {
+				remaining[i].Free = remaining[i].Free.sub(request)
+				remaining[i].Placed = append(remaining[i].Placed, pod)
+				placed = true
+				break
+			}
+		}
+
+		if !placed {
+			blocking = append(blocking, pod)
+		}
+	}
+
+	if len(blocking) == 0 {
+		s.nodes = remaining
+	}
+
+	return blocking
+}
+
+// Helper function to convert the requests of a pod.
+func podResources(pod corev1.Pod) resources {
+	cpu, mem := podRequests(pod.Spec)
+
+	return resources{
+		CPU:    cpu,
+		Memory: mem,
+		Pods:   1,
+	}
+}
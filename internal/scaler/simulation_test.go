@@ -0,0 +1,141 @@
+package scaler
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Helper function to build a node with an allocatable amount of cpu and memory.
+func testNode(name, cpu, mem string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(mem),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			},
+		},
+	}
+}
+
+// Helper function to build a pod which requests an amount of cpu and memory.
+func testPod(name, cpu, mem string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse(mem),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Helper function to build a candidate which runs the pods.
+func testCandidate(node corev1.Node, pods ...corev1.Pod) candidate {
+	c := candidate{
+		Node: node,
+		Pods: pods,
+	}
+
+	for _, pod := range pods {
+		c.Requested = c.Requested.add(podResources(pod))
+	}
+
+	return c
+}
+
+func TestSimulationRemove(t *testing.T) {
+	var (
+		a = testCandidate(testNode("a", "1", "1Gi"), testPod("a1", "600m", "256Mi"))
+		b = testCandidate(testNode("b", "1", "1Gi"), testPod("b1", "300m", "256Mi"))
+		c = testCandidate(testNode("c", "1", "1Gi"), testPod("c1", "300m", "256Mi"))
+
+		empty = testCandidate(testNode("e", "2", "2Gi"))
+	)
+
+	for _, tc := range []struct {
+		name       string
+		candidates []candidate
+		remove     []candidate
+		blocking   [][]string
+	}{
+		{
+			name:       "one node",
+			candidates: []candidate{a, b},
+			remove:     []candidate{a},
+			blocking:   [][]string{nil},
+		},
+		{
+			name:       "pods don't fit",
+			candidates: []candidate{a, b},
+			remove:     []candidate{b, a},
+			blocking:   [][]string{nil, {"a1", "b1"}},
+		},
+		{
+			name:       "two nodes which fit",
+			candidates: []candidate{b, c, empty},
+			remove:     []candidate{b, c},
+			blocking:   [][]string{nil, nil},
+		},
+		{
+			// The pods of a are placed onto b, so removing b has to reschedule them as well.
+			name:       "two nodes where the second received pods",
+			candidates: []candidate{a, b},
+			remove:     []candidate{a, b},
+			blocking:   [][]string{nil, {"a1", "b1"}},
+		},
+		{
+			name:       "pods rescheduled onto a node which is removed later",
+			candidates: []candidate{a, b, c},
+			remove:     []candidate{a, b},
+			blocking:   [][]string{nil, {"b1"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sim := newSimulation(tc.candidates)
+
+			for i, c := range tc.remove {
+				var names []string
+
+				for _, pod := range sim.remove(c) {
+					names = append(names, pod.ObjectMeta.Name)
+				}
+
+				if !equalStrings(names, tc.blocking[i]) {
+					t.Errorf("removing node %s: expected blocking pods %v, got %v", c.Node.ObjectMeta.Name, tc.blocking[i], names)
+				}
+			}
+		})
+	}
+}
+
+// Helper function to compare two lists of strings.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,141 @@
+package scaler
+
+import (
+	"math"
+	"time"
+)
+
+// StabilizationParams declares how the desired capacity is smoothed out, to stop the group flapping when demand spikes.
+type StabilizationParams struct {
+	// UpWindow only scales up to the lowest desired capacity calculated within the window.
+	UpWindow time.Duration
+	// DownWindow only scales down to the highest desired capacity calculated within the window.
+	DownWindow time.Duration
+	// UpMaxNodes which can be added within each UpPeriod.
+	UpMaxNodes int
+	// UpMaxPercent of the group which can be added within each UpPeriod.
+	UpMaxPercent float64
+	// UpPeriod which UpMaxNodes and UpMaxPercent apply to.
+	UpPeriod time.Duration
+}
+
+// recommendation is a desired capacity which was calculated for a group.
+type recommendation struct {
+	Time    time.Time `json:"time"`
+	Desired int64     `json:"desired"`
+}
+
+// scaleUp is a scale up event of a group.
+type scaleUp struct {
+	Time time.Time `json:"time"`
+	From int64     `json:"from"`
+	To   int64     `json:"to"`
+}
+
+// Helper function to drop the recommendations which are older than both windows.
+func trimRecommendations(history []recommendation, params StabilizationParams, now time.Time) []recommendation {
+	window := params.UpWindow
+
+	if params.DownWindow > window {
+		window = params.DownWindow
+	}
+
+	var trimmed []recommendation
+
+	for _, r := range history {
+		if !r.Time.Before(now.Add(-window)) {
+			trimmed = append(trimmed, r)
+		}
+	}
+
+	return trimmed
+}
+
+// Helper function to stabilize the desired capacity using the recommendations within each window.
+// Scaling up only goes as high as the lowest recommendation in the up window, while scaling down only
+// goes as low as the highest recommendation in the down window. The history must include the latest recommendation.
+func stabilize(history []recommendation, params StabilizationParams, current int64, now time.Time) int64 {
+	var (
+		up      int64 = math.MaxInt64
+		down    int64 = math.MinInt64
+		desired       = current
+	)
+
+	for i, r := range history {
+		// The latest recommendation is always within the windows, even when they are zero.
+		latest := i == len(history)-1
+
+		if latest || !r.Time.Before(now.Add(-params.UpWindow)) {
+			if r.Desired < up {
+				up = r.Desired
+			}
+		}
+
+		if latest || !r.Time.Before(now.Add(-params.DownWindow)) {
+			if r.Desired > down {
+				down = r.Desired
+			}
+		}
+	}
+
+	if desired < up {
+		desired = up
+	}
+
+	if desired > down {
+		desired = down
+	}
+
+	return desired
+}
+
+// Helper function to drop the scale up events which are older than the period.
+func trimScaleUps(events []scaleUp, params StabilizationParams, now time.Time) []scaleUp {
+	var trimmed []scaleUp
+
+	for _, e := range events {
+		if !e.Time.Before(now.Add(-params.UpPeriod)) {
+			trimmed = append(trimmed, e)
+		}
+	}
+
+	return trimmed
+}
+
+// Helper function to limit how many nodes can be added within the period.
+// When both a maximum amount of nodes and percent are declared, the one which allows the most nodes is used.
+func limitScaleUp(events []scaleUp, params StabilizationParams, current, desired int64) int64 {
+	if desired <= current || (params.UpMaxNodes == 0 && params.UpMaxPercent == 0) {
+		return desired
+	}
+
+	// The size of the group at the start of the period, before any scale up events within the period.
+	base := current
+
+	if len(events) > 0 {
+		base = events[0].From
+	}
+
+	allowance := int64(params.UpMaxNodes)
+
+	if byPercent := int64(math.Ceil(float64(base) * params.UpMaxPercent / 100)); byPercent > allowance {
+		allowance = byPercent
+	}
+
+	// A group which is empty still has to be able to add a node.
+	if allowance < 1 {
+		allowance = 1
+	}
+
+	limit := base + allowance
+
+	if limit < current {
+		limit = current
+	}
+
+	if desired > limit {
+		return limit
+	}
+
+	return desired
+}
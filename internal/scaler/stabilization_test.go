@@ -0,0 +1,200 @@
+package scaler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStabilize(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		params = StabilizationParams{
+			UpWindow:   3 * time.Minute,
+			DownWindow: 10 * time.Minute,
+		}
+	)
+
+	// Helper function to build a recommendation made some minutes ago.
+	ago := func(minutes int, desired int64) recommendation {
+		return recommendation{
+			Time:    now.Add(-time.Duration(minutes) * time.Minute),
+			Desired: desired,
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		history  []recommendation
+		params   StabilizationParams
+		current  int64
+		expected int64
+	}{
+		{
+			name:     "no windows",
+			history:  []recommendation{ago(5, 2), ago(0, 8)},
+			current:  5,
+			expected: 8,
+		},
+		{
+			name:     "no change",
+			history:  []recommendation{ago(0, 5)},
+			params:   params,
+			current:  5,
+			expected: 5,
+		},
+		{
+			name:     "scale up to the lowest recommendation in the up window",
+			history:  []recommendation{ago(2, 6), ago(1, 9), ago(0, 8)},
+			params:   params,
+			current:  5,
+			expected: 6,
+		},
+		{
+			name:     "scale up ignores recommendations before the up window",
+			history:  []recommendation{ago(5, 5), ago(2, 7), ago(0, 8)},
+			params:   params,
+			current:  5,
+			expected: 7,
+		},
+		{
+			name:     "no scale up when the up window recommended the current capacity",
+			history:  []recommendation{ago(2, 5), ago(0, 8)},
+			params:   params,
+			current:  5,
+			expected: 5,
+		},
+		{
+			name:     "scale down to the highest recommendation in the down window",
+			history:  []recommendation{ago(8, 4), ago(5, 3), ago(0, 2)},
+			params:   params,
+			current:  5,
+			expected: 4,
+		},
+		{
+			name:     "scale down ignores recommendations before the down window",
+			history:  []recommendation{ago(15, 9), ago(5, 3), ago(0, 2)},
+			params:   params,
+			current:  5,
+			expected: 3,
+		},
+		{
+			name:     "no scale down when the down window recommended the current capacity",
+			history:  []recommendation{ago(8, 5), ago(0, 2)},
+			params:   params,
+			current:  5,
+			expected: 5,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if desired := stabilize(tc.history, tc.params, tc.current, now); desired != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, desired)
+			}
+		})
+	}
+}
+
+func TestTrimRecommendations(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		params = StabilizationParams{
+			UpWindow:   3 * time.Minute,
+			DownWindow: 10 * time.Minute,
+		}
+		history = []recommendation{
+			{Time: now.Add(-15 * time.Minute), Desired: 1},
+			{Time: now.Add(-10 * time.Minute), Desired: 2},
+			{Time: now.Add(-5 * time.Minute), Desired: 3},
+			{Time: now, Desired: 4},
+		}
+	)
+
+	// Recommendations are kept for the longest window.
+	trimmed := trimRecommendations(history, params, now)
+
+	if len(trimmed) != 3 || trimmed[0].Desired != 2 {
+		t.Errorf("expected the last 3 recommendations, got %v", trimmed)
+	}
+}
+
+func TestLimitScaleUp(t *testing.T) {
+	var (
+		now    = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		events = []scaleUp{
+			{Time: now.Add(-2 * time.Minute), From: 10, To: 12},
+			{Time: now.Add(-time.Minute), From: 12, To: 13},
+		}
+	)
+
+	for _, tc := range []struct {
+		name     string
+		events   []scaleUp
+		params   StabilizationParams
+		current  int64
+		desired  int64
+		expected int64
+	}{
+		{
+			name:     "no limit",
+			current:  10,
+			desired:  50,
+			expected: 50,
+		},
+		{
+			name:     "scaling down is not limited",
+			params:   StabilizationParams{UpMaxNodes: 1},
+			current:  10,
+			desired:  5,
+			expected: 5,
+		},
+		{
+			name:     "limited by nodes",
+			params:   StabilizationParams{UpMaxNodes: 4},
+			current:  10,
+			desired:  20,
+			expected: 14,
+		},
+		{
+			name:     "limited by percent, rounded up",
+			params:   StabilizationParams{UpMaxPercent: 25},
+			current:  10,
+			desired:  20,
+			expected: 13,
+		},
+		{
+			name:     "the limit which allows the most nodes wins",
+			params:   StabilizationParams{UpMaxNodes: 4, UpMaxPercent: 50},
+			current:  10,
+			desired:  20,
+			expected: 15,
+		},
+		{
+			name:     "an empty group can add a node",
+			params:   StabilizationParams{UpMaxPercent: 50},
+			current:  0,
+			desired:  5,
+			expected: 1,
+		},
+		{
+			name:     "scale ups within the period count towards the limit",
+			events:   events,
+			params:   StabilizationParams{UpMaxNodes: 4},
+			current:  13,
+			desired:  20,
+			expected: 14,
+		},
+		{
+			name:     "the limit is never below the current capacity",
+			events:   events,
+			params:   StabilizationParams{UpMaxNodes: 1},
+			current:  13,
+			desired:  20,
+			expected: 13,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if desired := limitScaleUp(tc.events, tc.params, tc.current, tc.desired); desired != tc.expected {
+				t.Errorf("expected %d, got %d", tc.expected, desired)
+			}
+		})
+	}
+}
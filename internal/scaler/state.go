@@ -0,0 +1,139 @@
+package scaler
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// The ConfigMap key which the cooldowns of each group are stored under.
+	stateKeyCooldowns = "cooldowns"
+	// The ConfigMap key which the recent recommendations of each group are stored under.
+	stateKeyRecommendations = "recommendations"
+	// The ConfigMap key which the recent scale up events of each group are stored under.
+	stateKeyScaleUps = "scale-ups"
+)
+
+// StateParams declares the ConfigMap which the scaler's state is persisted in, so it survives a restart.
+type StateParams struct {
+	// Namespace of the ConfigMap.
+	Namespace string
+	// Name of the ConfigMap.
+	Name string
+}
+
+// state of the scaler which is persisted between restarts. Each field is keyed by the group name.
+type state struct {
+	Cooldowns       map[string]cooldown
+	Recommendations map[string][]recommendation
+	ScaleUps        map[string][]scaleUp
+}
+
+// Helper function to map each ConfigMap key to the field it is stored in.
+func (s *state) fields() map[string]interface{} {
+	return map[string]interface{}{
+		stateKeyCooldowns:       &s.Cooldowns,
+		stateKeyRecommendations: &s.Recommendations,
+		stateKeyScaleUps:        &s.ScaleUps,
+	}
+}
+
+// Helper function to load the state from the ConfigMap, which is empty when the ConfigMap does not exist yet.
+func loadState(k8s *kubernetes.Clientset, params StateParams) (*state, error) {
+	s := &state{
+		Cooldowns:       make(map[string]cooldown),
+		Recommendations: make(map[string][]recommendation),
+		ScaleUps:        make(map[string][]scaleUp),
+	}
+
+	cm, err := k8s.CoreV1().ConfigMaps(params.Namespace).Get(params.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for key, field := range s.fields() {
+		data, ok := cm.Data[key]
+		if !ok {
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(data), field); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode %s", key)
+		}
+	}
+
+	return s, nil
+}
+
+// Helper function to save the state to the ConfigMap, creating it if it does not exist.
+func (s *state) save(k8s *kubernetes.Clientset, params StateParams) error {
+	data := make(map[string]string)
+
+	for key, field := range s.fields() {
+		value, err := json.Marshal(field)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode %s", key)
+		}
+
+		data[key] = string(value)
+	}
+
+	cm, err := k8s.CoreV1().ConfigMaps(params.Namespace).Get(params.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = k8s.CoreV1().ConfigMaps(params.Namespace).Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: params.Namespace,
+				Name:      params.Name,
+			},
+			Data: data,
+		})
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+
+	for key, value := range data {
+		cm.Data[key] = value
+	}
+
+	_, err = k8s.CoreV1().ConfigMaps(params.Namespace).Update(cm)
+
+	return err
+}
+
+// Helper function to forget the groups which are no longer managed.
+func (s *state) prune(managed map[string]bool) {
+	for name := range s.Cooldowns {
+		if !managed[name] {
+			delete(s.Cooldowns, name)
+		}
+	}
+
+	for name := range s.Recommendations {
+		if !managed[name] {
+			delete(s.Recommendations, name)
+		}
+	}
+
+	for name := range s.ScaleUps {
+		if !managed[name] {
+			delete(s.ScaleUps, name)
+		}
+	}
+}
@@ -11,6 +11,7 @@ func main() {
 	app := kingpin.New("k8s-aws-autoscaler", "Kubernetes AWS Scaler: Deployments")
 
 	cmd.Watch(app)
+	cmd.Forecast(app)
 	cmd.Version(app)
 
 	kingpin.MustParse(app.Parse(os.Args[1:]))